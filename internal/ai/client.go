@@ -0,0 +1,195 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Client asks questions about notes through a Provider.
+type Client struct {
+	provider Provider
+}
+
+// NewClient returns a client that sends its prompts to p.
+func NewClient(p Provider) *Client {
+	return &Client{provider: p}
+}
+
+// Available reports whether the client can send requests.
+func (c *Client) Available() bool {
+	return c.Ready() == nil
+}
+
+// Ready returns why the client can't send requests, or nil.
+func (c *Client) Ready() error {
+	if c == nil || c.provider == nil {
+		return errors.New("no AI provider configured")
+	}
+	return c.provider.Ready()
+}
+
+// Name is the provider's name, for showing to the user.
+func (c *Client) Name() string {
+	if c == nil || c.provider == nil {
+		return "AI"
+	}
+	return c.provider.Name()
+}
+
+// Model is the model answers come from, or "" if there is no provider.
+func (c *Client) Model() string {
+	if c == nil || c.provider == nil {
+		return ""
+	}
+	return c.provider.Model()
+}
+
+// How much of the vault AskVault callers should send: the best
+// VaultPassages passages that fit in VaultTokens.
+const (
+	VaultPassages = 8
+	VaultTokens   = 6000
+)
+
+// NoteContext is a passage from a note, sent to AskVault as a source.
+type NoteContext struct {
+	ID      string // note ID, for citations
+	Title   string
+	Section string // heading the passage is under; "" for a whole note or its intro
+	Tags    []string
+	Body    string
+}
+
+// send sends req to the provider and returns the whole reply. If onChunk
+// is non-nil the reply is also passed to it as it arrives: in pieces when
+// the provider is a Streamer, otherwise in one go at the end.
+func (c *Client) send(ctx context.Context, req Request, onChunk func(string)) (string, error) {
+	s, ok := c.provider.(Streamer)
+	if onChunk == nil || !ok {
+		answer, err := c.provider.Generate(ctx, req)
+		if err == nil && onChunk != nil {
+			onChunk(answer)
+		}
+		return answer, err
+	}
+	var answer strings.Builder
+	err := s.Stream(ctx, req, func(chunk string) {
+		answer.WriteString(chunk)
+		onChunk(chunk)
+	})
+	return answer.String(), err
+}
+
+// AskVault answers a question from the given passages of the user's notes,
+// which the caller picks (see search.Retrieve and FollowUp). The model is
+// asked to cite them by number; Citations finds which it did. history is
+// the conversation so far, trimmed to HistoryTokens. onChunk, if non-nil,
+// receives the answer as it streams in.
+func (c *Client) AskVault(ctx context.Context, sources []NoteContext, history []Message, question string, onChunk func(string)) (string, error) {
+	if err := c.Ready(); err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for i, n := range sources {
+		tags := ""
+		if len(n.Tags) > 0 {
+			tags = " [" + strings.Join(n.Tags, ", ") + "]"
+		}
+		sb.WriteString(fmt.Sprintf("--- [%d] %s%s ---\n%s\n\n", i+1, sourceName(n), tags, n.Body))
+	}
+	if len(sources) == 0 {
+		sb.WriteString("(no notes matched the question)\n\n")
+	}
+
+	prompt := fmt.Sprintf(
+		"You are a personal knowledge assistant. Answer based on these excerpts from the user's notes vault. Be specific, and cite the excerpts you draw from by number, like [1] or [2][3]. If the excerpts don't answer the question, say so.\n\nEXCERPTS:\n%s\nQUESTION: %s",
+		sb.String(),
+		question,
+	)
+
+	return c.send(ctx, Request{
+		Messages: withHistory(history, prompt),
+	}, onChunk)
+}
+
+// sourceName is how a passage is named to the model and the user.
+func sourceName(n NoteContext) string {
+	if n.Section != "" {
+		return n.Title + " › " + n.Section
+	}
+	return n.Title
+}
+
+// Citation is a source an answer referred to.
+type Citation struct {
+	N       int    `json:"n"`  // the number the answer cites it by
+	ID      string `json:"id"` // the note's ID
+	Title   string `json:"title"`
+	Section string `json:"section,omitempty"`
+}
+
+// String formats c as "[N] Title › Section (id)".
+func (c Citation) String() string {
+	return fmt.Sprintf("[%d] %s (%s)", c.N, sourceName(NoteContext{Title: c.Title, Section: c.Section}), c.ID)
+}
+
+var citationRe = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// Citations returns the sources answer cites as [N] or [N, M], in order of
+// number. Numbers with no source are ignored.
+func Citations(answer string, sources []NoteContext) []Citation {
+	seen := map[int]bool{}
+	var out []Citation
+	for _, m := range citationRe.FindAllStringSubmatch(answer, -1) {
+		for _, f := range strings.Split(m[1], ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(f))
+			if err != nil || n < 1 || n > len(sources) || seen[n] {
+				continue
+			}
+			seen[n] = true
+			s := sources[n-1]
+			out = append(out, Citation{N: n, ID: s.ID, Title: s.Title, Section: s.Section})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].N < out[j].N })
+	return out
+}
+
+// Ask answers a question about one note. history is the conversation so
+// far, trimmed to HistoryTokens. onChunk, if non-nil, receives the answer
+// as it streams in.
+func (c *Client) Ask(ctx context.Context, noteTitle, noteContent string, history []Message, question string, onChunk func(string)) (string, error) {
+	if err := c.Ready(); err != nil {
+		return "", err
+	}
+
+	system := `You are a helpful assistant embedded in grove, a terminal note-taking app.
+You help the user think through their notes, ask clarifying questions, and surface unstated assumptions.
+Be concise. Push back when reasoning has gaps. Ask one probing question when useful.`
+
+	contextBlock := fmt.Sprintf("Note: %s\n\n%s", noteTitle, noteContent)
+	if len(contextBlock) > 4000 {
+		contextBlock = contextBlock[:4000] + "\n... (truncated)"
+	}
+
+	userPrompt := fmt.Sprintf("Context from my note:\n\n%s\n\nQuestion: %s", contextBlock, question)
+
+	return c.send(ctx, Request{
+		System:   system,
+		Messages: withHistory(history, userPrompt),
+	}, onChunk)
+}
+
+// withHistory is the trimmed conversation followed by the new prompt. The
+// earlier questions go without the notes they were asked with; the model
+// gets fresh ones with the prompt.
+func withHistory(history []Message, prompt string) []Message {
+	msgs := append([]Message(nil), TrimHistory(history, HistoryTokens)...)
+	return append(msgs, Message{Role: RoleUser, Content: prompt})
+}
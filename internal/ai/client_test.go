@@ -0,0 +1,97 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_AskVault(t *testing.T) {
+	var got ollamaRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"message":{"role":"assistant","content":"see Alpha"}}`))
+	}))
+	defer srv.Close()
+
+	p, _ := NewProvider(Settings{Provider: "ollama", BaseURL: srv.URL})
+	c := NewClient(p)
+	answer, err := c.AskVault(context.Background(), []NoteContext{{Title: "Alpha", Tags: []string{"x"}, Body: "alpha body"}}, nil, "what is alpha?", nil)
+	if err != nil || answer != "see Alpha" {
+		t.Fatalf("AskVault = %q, %v", answer, err)
+	}
+	prompt := got.Messages[0].Content
+	if !strings.Contains(prompt, "--- [1] Alpha [x] ---\nalpha body") || !strings.Contains(prompt, "QUESTION: what is alpha?") {
+		t.Errorf("prompt = %q", prompt)
+	}
+	if c.Name() != "Ollama" {
+		t.Errorf("Name = %q", c.Name())
+	}
+
+	var none *Client
+	if none.Available() {
+		t.Error("nil client should not be available")
+	}
+}
+
+func TestClient_streams(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, part := range []string{"Once ", "upon ", "a time"} {
+			fmt.Fprintf(w, "data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":%q}]}}]}\n\n", part)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer srv.Close()
+
+	p, _ := NewProvider(Settings{APIKey: "k", BaseURL: srv.URL})
+	var chunks []string
+	answer, err := NewClient(p).Ask(context.Background(), "Story", "body", nil, "tell me", func(s string) {
+		chunks = append(chunks, s)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "Once upon a time" || len(chunks) != 3 {
+		t.Errorf("answer %q from chunks %q", answer, chunks)
+	}
+
+	// Providers that can't stream deliver the answer as one chunk
+	srv2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message":{"role":"assistant","content":"whole"}}`))
+	}))
+	defer srv2.Close()
+	p, _ = NewProvider(Settings{Provider: "ollama", BaseURL: srv2.URL})
+	chunks = nil
+	if _, err := NewClient(p).Ask(context.Background(), "T", "b", nil, "q", func(s string) { chunks = append(chunks, s) }); err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 1 || chunks[0] != "whole" {
+		t.Errorf("chunks = %q", chunks)
+	}
+}
+
+func TestCitations(t *testing.T) {
+	sources := []NoteContext{
+		{ID: "atlas", Title: "Atlas", Section: "Goals"},
+		{ID: "daily/2026-10-01", Title: "Daily"},
+		{ID: "atlas", Title: "Atlas", Section: "Risks"},
+	}
+	answer := "Ship by March [3]. Risks are listed [1, 3] and [9]; see also [2]."
+	got := Citations(answer, sources)
+	var strs []string
+	for _, c := range got {
+		strs = append(strs, c.String())
+	}
+	want := "[1] Atlas › Goals (atlas)|[2] Daily (daily/2026-10-01)|[3] Atlas › Risks (atlas)"
+	if strings.Join(strs, "|") != want {
+		t.Errorf("Citations = %q", strs)
+	}
+	if got := Citations("no sources here", sources); got != nil {
+		t.Errorf("expected none, got %v", got)
+	}
+}
@@ -0,0 +1,48 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DigestTokens is how much of the period's notes Digest sends, shared
+// evenly between them.
+const DigestTokens = 12000
+
+// Digest writes a review of the notes from period (say, "the week of
+// 12 Oct 2026"): themes, decisions, open action items and connections
+// between ideas, referring to the notes by [[wiki-link]]. onChunk, if
+// non-nil, receives the digest as it streams in.
+func (c *Client) Digest(ctx context.Context, period string, sources []NoteContext, onChunk func(string)) (string, error) {
+	if err := c.Ready(); err != nil {
+		return "", err
+	}
+	if len(sources) == 0 {
+		return "", errors.New("no notes to review")
+	}
+
+	share := DigestTokens * 4 / len(sources)
+	var sb strings.Builder
+	for _, n := range sources {
+		tags := ""
+		if len(n.Tags) > 0 {
+			tags = " [" + strings.Join(n.Tags, ", ") + "]"
+		}
+		sb.WriteString(fmt.Sprintf("--- %s%s ---\n%s\n\n", n.Title, tags, clip(strings.TrimSpace(n.Body), share)))
+	}
+
+	prompt := fmt.Sprintf(
+		"You are a personal knowledge assistant writing a review of %s from the user's notes below. Write markdown with these sections:\n\n"+
+			"## Themes\nWhat the user spent their time and thought on.\n\n"+
+			"## Decisions\nWhat was decided, and why if the notes say.\n\n"+
+			"## Open action items\nTasks and questions still open, as a \"- [ ]\" checklist.\n\n"+
+			"## Connections\nIdeas in different notes that relate to each other.\n\n"+
+			"Refer to notes by wiki-linking their titles exactly, like [[%s]]. Be concise and only use what the notes say; leave a section out if there's nothing for it.\n\nNOTES:\n%s",
+		period, sources[0].Title, sb.String(),
+	)
+	return c.send(ctx, Request{
+		Messages: []Message{{Role: RoleUser, Content: prompt}},
+	}, onChunk)
+}
@@ -0,0 +1,41 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDigest(t *testing.T) {
+	var req ollamaRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.Write([]byte(`{"message":{"role":"assistant","content":"## Themes\n[[Daily 2026-10-12]]"}}`))
+	}))
+	defer srv.Close()
+
+	p, _ := NewProvider(Settings{Provider: "ollama", BaseURL: srv.URL})
+	c := NewClient(p)
+	long := strings.Repeat("word ", DigestTokens)
+	got, err := c.Digest(context.Background(), "the week of 12 Oct 2026", []NoteContext{
+		{Title: "Daily 2026-10-12", Tags: []string{"daily"}, Body: "shipped atlas"},
+		{Title: "Atlas", Body: long},
+	}, nil)
+	if err != nil || !strings.HasPrefix(got, "## Themes") {
+		t.Fatalf("Digest = %q, %v", got, err)
+	}
+	prompt := req.Messages[0].Content
+	if !strings.Contains(prompt, "review of the week of 12 Oct 2026") || !strings.Contains(prompt, "--- Daily 2026-10-12 [daily] ---\nshipped atlas") {
+		t.Errorf("prompt:\n%.500s", prompt)
+	}
+	if len(prompt) > DigestTokens*4+2000 {
+		t.Errorf("prompt is %d bytes, over budget", len(prompt))
+	}
+
+	if _, err := c.Digest(context.Background(), "a week", nil, nil); err == nil {
+		t.Error("Digest with no notes succeeded")
+	}
+}
@@ -2,45 +2,64 @@ package ai
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
 
-const geminiURL = "https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s"
+const (
+	geminiBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+	geminiModel   = "gemini-2.5-flash"
+)
 
-type Client struct {
-	apiKey string
-	model  string
-	http   *http.Client
+// gemini talks to Google's Gemini generateContent API.
+type gemini struct {
+	apiKey  string
+	model   string
+	baseURL string
+	http    *http.Client
 }
 
-func NewClient(apiKey, model string) *Client {
-	if model == "" {
-		model = "gemini-2.5-flash"
+func newGemini(s Settings) *gemini {
+	g := &gemini{
+		apiKey:  s.APIKey,
+		model:   s.Model,
+		baseURL: strings.TrimSuffix(s.BaseURL, "/"),
+		http:    &http.Client{Timeout: 60 * time.Second},
+	}
+	if g.model == "" {
+		g.model = geminiModel
 	}
-	return &Client{
-		apiKey: apiKey,
-		model:  model,
-		http:   &http.Client{Timeout: 60 * time.Second},
+	if g.baseURL == "" {
+		g.baseURL = geminiBaseURL
 	}
+	return g
 }
 
-func (c *Client) Available() bool {
-	return c.apiKey != ""
+func (g *gemini) Name() string  { return "Gemini" }
+func (g *gemini) Model() string { return g.model }
+
+func (g *gemini) Ready() error {
+	if g.apiKey == "" {
+		return errors.New("no Gemini API key configured (check ~/.config/pairy/config.json or set GEMINI_API_KEY)")
+	}
+	return nil
 }
 
 type geminiRequest struct {
-	Contents         []geminiContent  `json:"contents"`
+	Contents          []geminiContent `json:"contents"`
 	SystemInstruction *geminiContent  `json:"systemInstruction,omitempty"`
 }
 
 type geminiContent struct {
-	Role  string        `json:"role,omitempty"`
-	Parts []geminiPart  `json:"parts"`
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
 }
 
 type geminiPart struct {
@@ -56,143 +75,119 @@ type geminiResponse struct {
 	} `json:"error"`
 }
 
-// NoteContext holds the data sent to AskVault.
-type NoteContext struct {
-	Title string
-	Tags  []string
-	Body  string
-}
-
-// AskVault sends all notes as context and answers a vault-wide question.
-// If there are more than 20 notes, each body is truncated to 500 chars.
-func (c *Client) AskVault(notesCtx []NoteContext, question string) (string, error) {
-	if !c.Available() {
-		return "", fmt.Errorf("no Gemini API key configured (check ~/.config/pairy/config.json or set GEMINI_API_KEY)")
+// request converts req to Gemini's format.
+func (g *gemini) request(req Request) geminiRequest {
+	body := geminiRequest{}
+	if req.System != "" {
+		body.SystemInstruction = &geminiContent{Parts: []geminiPart{{Text: req.System}}}
 	}
-
-	const maxNotes = 20
-	const truncateAt = 500
-
-	var sb strings.Builder
-	for i, n := range notesCtx {
-		body := n.Body
-		if len(notesCtx) > maxNotes && len(body) > truncateAt {
-			body = body[:truncateAt] + "..."
+	for _, m := range req.Messages {
+		role := "user"
+		if m.Role == RoleAssistant {
+			role = "model"
 		}
-		tags := ""
-		if len(n.Tags) > 0 {
-			tags = " [" + strings.Join(n.Tags, ", ") + "]"
-		}
-		sb.WriteString(fmt.Sprintf("--- Note %d: %s%s ---\n%s\n\n", i+1, n.Title, tags, body))
+		body.Contents = append(body.Contents, geminiContent{Role: role, Parts: []geminiPart{{Text: m.Content}}})
 	}
+	return body
+}
 
-	prompt := fmt.Sprintf(
-		"You are a personal knowledge assistant. Answer based on the user's notes vault. Be specific and cite which note titles you're drawing from.\n\nNOTES:\n%s\nQUESTION: %s",
-		sb.String(),
-		question,
-	)
+func (g *gemini) endpoint(method string) string {
+	return fmt.Sprintf("%s/models/%s:%s", g.baseURL, url.PathEscape(g.model), method)
+}
 
-	req := geminiRequest{
-		Contents: []geminiContent{
-			{Role: "user", Parts: []geminiPart{{Text: prompt}}},
-		},
+// text joins the parts of the first candidate in res.
+func (res *geminiResponse) text() string {
+	if len(res.Candidates) == 0 {
+		return ""
 	}
-
-	body, err := json.Marshal(req)
-	if err != nil {
-		return "", err
+	var parts []string
+	for _, p := range res.Candidates[0].Content.Parts {
+		parts = append(parts, p.Text)
 	}
+	return strings.Join(parts, "")
+}
 
-	url := fmt.Sprintf(geminiURL, c.model, c.apiKey)
-	resp, err := c.http.Post(url, "application/json", bytes.NewReader(body))
-	if err != nil {
+func (g *gemini) Generate(ctx context.Context, req Request) (string, error) {
+	if err := g.Ready(); err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-
-	data, err := io.ReadAll(resp.Body)
+	header := http.Header{"X-Goog-Api-Key": {g.apiKey}}
+	data, status, err := postJSON(ctx, g.http, g.endpoint("generateContent"), header, g.request(req))
 	if err != nil {
 		return "", err
 	}
 
 	var result geminiResponse
-	if err := json.Unmarshal(data, &result); err != nil {
-		return "", fmt.Errorf("parse error: %w\nraw: %s", err, string(data))
+	if err := decodeResponse(data, status, &result); err != nil {
+		return "", err
 	}
-
 	if result.Error != nil {
 		return "", fmt.Errorf("API error: %s", result.Error.Message)
 	}
-
+	if err := statusError(status, data); err != nil {
+		return "", err
+	}
 	if len(result.Candidates) == 0 {
 		return "", fmt.Errorf("no response from API")
 	}
-
-	var parts []string
-	for _, p := range result.Candidates[0].Content.Parts {
-		parts = append(parts, p.Text)
-	}
-	return strings.Join(parts, ""), nil
+	return result.text(), nil
 }
 
-func (c *Client) Ask(noteTitle, noteContent, question string) (string, error) {
-	if !c.Available() {
-		return "", fmt.Errorf("no Gemini API key configured (check ~/.config/pairy/config.json or set GEMINI_API_KEY)")
+// Stream uses streamGenerateContent, which sends each piece of the answer
+// as a server-sent event holding a partial response.
+func (g *gemini) Stream(ctx context.Context, req Request, chunk func(string)) error {
+	if err := g.Ready(); err != nil {
+		return err
 	}
-
-	system := `You are a helpful assistant embedded in grove, a terminal note-taking app.
-You help the user think through their notes, ask clarifying questions, and surface unstated assumptions.
-Be concise. Push back when reasoning has gaps. Ask one probing question when useful.`
-
-	contextBlock := fmt.Sprintf("Note: %s\n\n%s", noteTitle, noteContent)
-	if len(contextBlock) > 4000 {
-		contextBlock = contextBlock[:4000] + "\n... (truncated)"
-	}
-
-	userPrompt := fmt.Sprintf("Context from my note:\n\n%s\n\nQuestion: %s", contextBlock, question)
-
-	req := geminiRequest{
-		SystemInstruction: &geminiContent{
-			Parts: []geminiPart{{Text: system}},
-		},
-		Contents: []geminiContent{
-			{Role: "user", Parts: []geminiPart{{Text: userPrompt}}},
-		},
-	}
-
-	body, err := json.Marshal(req)
+	body, err := json.Marshal(g.request(req))
 	if err != nil {
-		return "", err
+		return err
 	}
-
-	url := fmt.Sprintf(geminiURL, c.model, c.apiKey)
-	resp, err := c.http.Post(url, "application/json", bytes.NewReader(body))
+	hreq, err := http.NewRequestWithContext(ctx, http.MethodPost, g.endpoint("streamGenerateContent")+"?alt=sse", bytes.NewReader(body))
 	if err != nil {
-		return "", err
+		return err
 	}
-	defer resp.Body.Close()
-
-	data, err := io.ReadAll(resp.Body)
+	hreq.Header.Set("Content-Type", "application/json")
+	hreq.Header.Set("X-Goog-Api-Key", g.apiKey)
+	resp, err := doStream(hreq)
 	if err != nil {
-		return "", err
+		return err
 	}
+	defer resp.Body.Close()
 
-	var result geminiResponse
-	if err := json.Unmarshal(data, &result); err != nil {
-		return "", fmt.Errorf("parse error: %w\nraw: %s", err, string(data))
+	if resp.StatusCode >= 300 {
+		// Errors come back as a plain JSON response, not a stream
+		data, _ := io.ReadAll(resp.Body)
+		var result geminiResponse
+		if json.Unmarshal(data, &result) == nil && result.Error != nil {
+			return fmt.Errorf("API error: %s", result.Error.Message)
+		}
+		return statusError(resp.StatusCode, data)
 	}
 
-	if result.Error != nil {
-		return "", fmt.Errorf("API error: %s", result.Error.Message)
+	got := false
+	err = readSSE(resp.Body, func(data []byte) error {
+		var part geminiResponse
+		if err := json.Unmarshal(data, &part); err != nil {
+			return fmt.Errorf("parse error: %w\nraw: %s", err, string(data))
+		}
+		if part.Error != nil {
+			return fmt.Errorf("API error: %s", part.Error.Message)
+		}
+		if t := part.text(); t != "" {
+			got = true
+			chunk(t)
+		}
+		return nil
+	})
+	if ctx.Err() != nil {
+		return ctx.Err()
 	}
-
-	if len(result.Candidates) == 0 {
-		return "", fmt.Errorf("no response from API")
+	if err != nil {
+		return err
 	}
-
-	var parts []string
-	for _, p := range result.Candidates[0].Content.Parts {
-		parts = append(parts, p.Text)
+	if !got {
+		return fmt.Errorf("no response from API")
 	}
-	return strings.Join(parts, ""), nil
+	return nil
 }
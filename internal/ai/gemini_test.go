@@ -0,0 +1,168 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGemini(t *testing.T) {
+	var got geminiRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/gemini-test:generateContent" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if k := r.Header.Get("X-Goog-Api-Key"); k != "secret" {
+			t.Errorf("api key header = %q", k)
+		}
+		if r.URL.RawQuery != "" {
+			t.Errorf("key leaked into the URL: %s", r.URL)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"Hello, "},{"text":"world"}]}}]}`))
+	}))
+	defer srv.Close()
+
+	p, _ := NewProvider(Settings{Provider: "gemini", APIKey: "secret", Model: "gemini-test", BaseURL: srv.URL})
+	answer, err := p.Generate(context.Background(), Request{
+		System: "be brief",
+		Messages: []Message{
+			{Role: RoleUser, Content: "hi"},
+			{Role: RoleAssistant, Content: "hello"},
+			{Role: RoleUser, Content: "again"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "Hello, world" {
+		t.Errorf("answer = %q", answer)
+	}
+	if got.SystemInstruction == nil || got.SystemInstruction.Parts[0].Text != "be brief" {
+		t.Errorf("system instruction = %+v", got.SystemInstruction)
+	}
+	if len(got.Contents) != 3 || got.Contents[1].Role != "model" || got.Contents[2].Parts[0].Text != "again" {
+		t.Errorf("contents = %+v", got.Contents)
+	}
+}
+
+func TestGemini_errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"code":400,"message":"API key not valid"}}`))
+	}))
+	defer srv.Close()
+
+	p, _ := NewProvider(Settings{APIKey: "bad", BaseURL: srv.URL})
+	_, err := p.Generate(context.Background(), Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}})
+	if err == nil || !strings.Contains(err.Error(), "API key not valid") {
+		t.Errorf("err = %v", err)
+	}
+
+	p, _ = NewProvider(Settings{BaseURL: srv.URL})
+	if p.Ready() == nil {
+		t.Error("Gemini without a key should not be ready")
+	}
+}
+
+func TestGemini_stream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/gemini-2.5-flash:streamGenerateContent" || r.URL.Query().Get("alt") != "sse" {
+			t.Errorf("url = %s", r.URL)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"Hel\"}]}}]}\r\n\r\n"))
+		w.Write([]byte(": keep-alive\n\n"))
+		w.Write([]byte("data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"lo\"}]}}]}\n\n"))
+	}))
+	defer srv.Close()
+
+	p, _ := NewProvider(Settings{APIKey: "k", BaseURL: srv.URL})
+	var got []string
+	err := p.(Streamer).Stream(context.Background(), Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}}, func(s string) {
+		got = append(got, s)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, "|") != "Hel|lo" {
+		t.Errorf("chunks = %q", got)
+	}
+}
+
+func TestGemini_streamCancel(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"first\"}]}}]}\n\n"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	p, _ := NewProvider(Settings{APIKey: "k", BaseURL: srv.URL})
+	ctx, cancel := context.WithCancel(context.Background())
+	err := p.(Streamer).Stream(ctx, Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}}, func(s string) {
+		cancel() // stop after the first chunk
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestGemini_streamError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":{"code":429,"message":"quota exceeded"}}`))
+	}))
+	defer srv.Close()
+
+	p, _ := NewProvider(Settings{APIKey: "k", BaseURL: srv.URL})
+	err := p.(Streamer).Stream(context.Background(), Request{}, func(string) {})
+	if err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestGemini_streamStalls(t *testing.T) {
+	defer func(idle time.Duration, c *http.Client) { streamIdle, streamClient = idle, c }(streamIdle, streamClient)
+	streamIdle = 50 * time.Millisecond
+	streamClient = newStreamClient(streamIdle)
+
+	for name, first := range map[string]string{
+		"no headers": "",
+		"no chunks":  "data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"first\"}]}}]}\n\n",
+	} {
+		release := make(chan struct{})
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if first != "" {
+				w.Write([]byte(first))
+				w.(http.Flusher).Flush()
+			}
+			<-release
+		}))
+		p, _ := NewProvider(Settings{APIKey: "k", BaseURL: srv.URL})
+		done := make(chan error, 1)
+		go func() {
+			done <- p.(Streamer).Stream(context.Background(), Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}}, func(string) {})
+		}()
+		select {
+		case err := <-done:
+			if err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+		case <-time.After(5 * time.Second):
+			t.Errorf("%s: stream never gave up", name)
+		}
+		close(release)
+		srv.Close()
+	}
+}
@@ -0,0 +1,95 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// HistoryTokens is how much of an earlier conversation Ask and AskVault
+// send along with a new question.
+const HistoryTokens = 4000
+
+// maxSessionMessages bounds how much conversation a Session file keeps.
+const maxSessionMessages = 40
+
+// TrimHistory returns the most recent messages of history that fit in
+// maxTokens, at about four characters a token. Whole question-and-answer
+// turns are dropped from the front, so the result starts with a user
+// message.
+func TrimHistory(history []Message, maxTokens int) []Message {
+	start := len(history)
+	used := 0
+	for i := len(history) - 1; i >= 0; i-- {
+		used += (len(history[i].Content) + 3) / 4
+		if used > maxTokens {
+			break
+		}
+		if history[i].Role == RoleUser {
+			start = i
+		}
+	}
+	return history[start:]
+}
+
+// FollowUp returns the text to search the vault with for question, asked
+// after history: the previous question as well, so "and the risks?" still
+// finds the notes the conversation is about.
+func FollowUp(history []Message, question string) string {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role == RoleUser {
+			return history[i].Content + " " + question
+		}
+	}
+	return question
+}
+
+// Session is a vault conversation kept between runs of grove ask.
+type Session struct {
+	Updated  time.Time `json:"updated"`
+	Messages []Message `json:"messages"`
+}
+
+// LoadSession reads the session saved at path. A missing file is an empty
+// session.
+func LoadSession(path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Session{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Add appends a question and its answer to the session.
+func (s *Session) Add(question, answer string) {
+	s.Messages = append(s.Messages,
+		Message{Role: RoleUser, Content: question},
+		Message{Role: RoleAssistant, Content: strings.TrimSpace(answer)},
+	)
+	if n := len(s.Messages); n > maxSessionMessages {
+		s.Messages = s.Messages[n-maxSessionMessages:]
+	}
+	s.Updated = time.Now()
+}
+
+// Save writes the session to path, creating its directory.
+func (s *Session) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
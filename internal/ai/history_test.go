@@ -0,0 +1,96 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTrimHistory(t *testing.T) {
+	long := strings.Repeat("x", 400) // 100 tokens
+	history := []Message{
+		{Role: RoleUser, Content: "q1"},
+		{Role: RoleAssistant, Content: long},
+		{Role: RoleUser, Content: "q2"},
+		{Role: RoleAssistant, Content: long},
+	}
+	if got := TrimHistory(history, 1000); len(got) != 4 {
+		t.Errorf("everything fits: kept %d", len(got))
+	}
+	// Room for the last answer and part of the first: start at q2
+	if got := TrimHistory(history, 150); len(got) != 2 || got[0].Content != "q2" {
+		t.Errorf("trimmed = %+v", got)
+	}
+	if got := TrimHistory(history, 50); len(got) != 0 {
+		t.Errorf("nothing fits: kept %+v", got)
+	}
+}
+
+func TestFollowUp(t *testing.T) {
+	history := []Message{{Role: RoleUser, Content: "atlas budget"}, {Role: RoleAssistant, Content: "40k"}}
+	if got := FollowUp(history, "and the risks?"); got != "atlas budget and the risks?" {
+		t.Errorf("FollowUp = %q", got)
+	}
+	if got := FollowUp(nil, "hi"); got != "hi" {
+		t.Errorf("FollowUp without history = %q", got)
+	}
+}
+
+func TestAsk_sendsHistoryAsGeminiContents(t *testing.T) {
+	var got geminiRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"candidates":[{"content":{"parts":[{"text":"ok"}]}}]}`))
+	}))
+	defer srv.Close()
+
+	p, _ := NewProvider(Settings{APIKey: "k", BaseURL: srv.URL})
+	history := []Message{
+		{Role: RoleUser, Content: "list three risks"},
+		{Role: RoleAssistant, Content: "1. cost 2. time 3. scope"},
+	}
+	if _, err := NewClient(p).Ask(context.Background(), "Plan", "body", history, "expand on point 2", nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Contents) != 3 {
+		t.Fatalf("contents = %+v", got.Contents)
+	}
+	roles := got.Contents[0].Role + "," + got.Contents[1].Role + "," + got.Contents[2].Role
+	if roles != "user,model,user" || got.Contents[1].Parts[0].Text != "1. cost 2. time 3. scope" {
+		t.Errorf("contents = %+v", got.Contents)
+	}
+	if !strings.Contains(got.Contents[2].Parts[0].Text, "expand on point 2") {
+		t.Errorf("last turn = %q", got.Contents[2].Parts[0].Text)
+	}
+}
+
+func TestSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grove", "session.json")
+	s, err := LoadSession(path)
+	if err != nil || len(s.Messages) != 0 {
+		t.Fatalf("missing file: %+v, %v", s, err)
+	}
+	for i := 0; i < maxSessionMessages; i++ {
+		s.Add("q", "a\n")
+	}
+	s.Add("last q", "last a")
+	if err := s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = LoadSession(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := len(s.Messages)
+	if n != maxSessionMessages || s.Messages[0].Role != RoleUser || s.Messages[n-1].Content != "last a" || s.Updated.IsZero() {
+		t.Errorf("reloaded %d messages, last %+v", n, s.Messages[n-1])
+	}
+	if s.Messages[1].Content != "a" {
+		t.Errorf("answers should be trimmed: %q", s.Messages[1].Content)
+	}
+}
@@ -0,0 +1,131 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LogEntry is one answered question in a Log.
+type LogEntry struct {
+	Note     string     `json:"note,omitempty"` // ID of the note asked about; "" for the vault
+	Time     time.Time  `json:"time"`
+	Model    string     `json:"model,omitempty"`
+	Question string     `json:"question"`
+	Answer   string     `json:"answer"`
+	Sources  []Citation `json:"sources,omitempty"`
+}
+
+// Log keeps every question asked of the AI, with its answer, in a JSON
+// Lines file beside the vault. A nil Log records nothing.
+type Log struct {
+	path string
+}
+
+// OpenLog returns the log kept at path, or nil if path is "".
+func OpenLog(path string) *Log {
+	if path == "" {
+		return nil
+	}
+	return &Log{path: path}
+}
+
+// Append adds e to the end of the log, creating the file if needed.
+func (l *Log) Append(e LogEntry) error {
+	if l == nil {
+		return nil
+	}
+	e.Answer = strings.TrimSpace(e.Answer)
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Entries returns the log oldest first. A missing log is empty, and lines
+// that can't be read (say, cut short by a crash) are skipped.
+func (l *Log) Entries() ([]LogEntry, error) {
+	if l == nil {
+		return nil, nil
+	}
+	data, err := os.ReadFile(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []LogEntry
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, len(data)+1)
+	for sc.Scan() {
+		var e LogEntry
+		if json.Unmarshal(sc.Bytes(), &e) == nil {
+			out = append(out, e)
+		}
+	}
+	return out, sc.Err()
+}
+
+// ForNote returns the entries about the note id, or about the vault if id
+// is "".
+func (l *Log) ForNote(id string) ([]LogEntry, error) {
+	all, err := l.Entries()
+	if err != nil {
+		return nil, err
+	}
+	var out []LogEntry
+	for _, e := range all {
+		if e.Note == id {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// Rename moves the entries for the note oldID to newID, and updates
+// sources citing it, so a renamed note keeps its history.
+func (l *Log) Rename(oldID, newID string) error {
+	all, err := l.Entries()
+	if err != nil || len(all) == 0 {
+		return err
+	}
+	var buf bytes.Buffer
+	for _, e := range all {
+		if e.Note == oldID {
+			e.Note = newID
+		}
+		for i := range e.Sources {
+			if e.Sources[i].ID == oldID {
+				e.Sources[i].ID = newID
+			}
+		}
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(line, '\n'))
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.path)
+}
@@ -0,0 +1,54 @@
+package ai
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "ai-log.jsonl")
+	l := OpenLog(path)
+	if got, err := l.Entries(); err != nil || got != nil {
+		t.Fatalf("missing log: %v, %v", got, err)
+	}
+	at := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	entries := []LogEntry{
+		{Note: "projects/atlas", Time: at, Question: "who owns it?", Answer: " Sam \n"},
+		{Time: at, Question: "what's late?", Answer: "Atlas [1]", Sources: []Citation{{N: 1, ID: "projects/atlas", Title: "Atlas"}}},
+		{Note: "other", Time: at, Question: "q", Answer: "a"},
+	}
+	for _, e := range entries {
+		if err := l.Append(e); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	// A line cut short by a crash doesn't lose the rest
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString(`{"note":"projects/at` + "\n")
+	f.Close()
+
+	atlas, err := l.ForNote("projects/atlas")
+	if err != nil || len(atlas) != 1 || atlas[0].Answer != "Sam" || !atlas[0].Time.Equal(at) {
+		t.Fatalf("ForNote(atlas) = %+v, %v", atlas, err)
+	}
+	if vault, _ := l.ForNote(""); len(vault) != 1 || vault[0].Sources[0].Title != "Atlas" {
+		t.Errorf("ForNote(vault) = %+v", vault)
+	}
+
+	if err := l.Rename("projects/atlas", "projects/atlas-2"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	if got, _ := l.ForNote("projects/atlas-2"); len(got) != 1 || got[0].Question != "who owns it?" {
+		t.Errorf("after rename: %+v", got)
+	}
+	if vault, _ := l.ForNote(""); vault[0].Sources[0].ID != "projects/atlas-2" {
+		t.Errorf("source not renamed: %+v", vault[0].Sources)
+	}
+
+	var none *Log
+	if err := none.Append(entries[0]); err != nil {
+		t.Errorf("nil log Append: %v", err)
+	}
+}
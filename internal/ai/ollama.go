@@ -0,0 +1,75 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	ollamaBaseURL = "http://localhost:11434"
+	ollamaModel   = "llama3.2"
+)
+
+// ollama talks to a local Ollama server's chat API.
+type ollama struct {
+	model   string
+	baseURL string
+	http    *http.Client
+}
+
+func newOllama(s Settings) *ollama {
+	o := &ollama{
+		model:   s.Model,
+		baseURL: strings.TrimSuffix(s.BaseURL, "/"),
+		// Local models can take a while, especially on first load
+		http: &http.Client{Timeout: 5 * time.Minute},
+	}
+	if o.model == "" {
+		o.model = ollamaModel
+	}
+	if o.baseURL == "" {
+		o.baseURL = ollamaBaseURL
+	}
+	return o
+}
+
+func (o *ollama) Name() string  { return "Ollama" }
+func (o *ollama) Model() string { return o.model }
+
+// Ready always succeeds: Ollama needs no key, and whether the server is
+// running only shows when a request is made.
+func (o *ollama) Ready() error { return nil }
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []openAIMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+}
+
+type ollamaResponse struct {
+	Message openAIMessage `json:"message"`
+	Error   string        `json:"error"`
+}
+
+func (o *ollama) Generate(ctx context.Context, req Request) (string, error) {
+	body := ollamaRequest{Model: o.model, Messages: chatMessages(req)}
+	data, status, err := postJSON(ctx, o.http, o.baseURL+"/api/chat", nil, body)
+	if err != nil {
+		return "", err
+	}
+
+	var result ollamaResponse
+	if err := decodeResponse(data, status, &result); err != nil {
+		return "", err
+	}
+	if result.Error != "" {
+		return "", fmt.Errorf("API error: %s", result.Error)
+	}
+	if err := statusError(status, data); err != nil {
+		return "", err
+	}
+	return result.Message.Content, nil
+}
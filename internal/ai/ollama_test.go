@@ -0,0 +1,57 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOllama(t *testing.T) {
+	var got ollamaRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		if got.Model == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"model \"missing\" not found, try pulling it first"}`))
+			return
+		}
+		w.Write([]byte(`{"model":"llama-test","message":{"role":"assistant","content":"local answer"},"done":true}`))
+	}))
+	defer srv.Close()
+
+	p, _ := NewProvider(Settings{Provider: "Ollama", Model: "llama-test", BaseURL: srv.URL})
+	if err := p.Ready(); err != nil {
+		t.Fatalf("Ready: %v", err)
+	}
+	answer, err := p.Generate(context.Background(), Request{
+		System:   "be brief",
+		Messages: []Message{{Role: RoleUser, Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "local answer" {
+		t.Errorf("answer = %q", answer)
+	}
+	if got.Stream || len(got.Messages) != 2 || got.Messages[0].Role != "system" {
+		t.Errorf("request = %+v", got)
+	}
+
+	p, _ = NewProvider(Settings{Provider: "ollama", Model: "missing", BaseURL: srv.URL})
+	_, err = p.Generate(context.Background(), Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}})
+	if err == nil || !strings.Contains(err.Error(), "try pulling it first") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestNewProvider_unknown(t *testing.T) {
+	if _, err := NewProvider(Settings{Provider: "clippy"}); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+}
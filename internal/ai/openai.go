@@ -0,0 +1,114 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	openAIBaseURL = "https://api.openai.com/v1"
+	openAIModel   = "gpt-4o-mini"
+)
+
+// openAI talks to an OpenAI-compatible chat completions API: OpenAI
+// itself, or a gateway or local server that speaks the same protocol.
+type openAI struct {
+	apiKey  string
+	model   string
+	baseURL string
+	http    *http.Client
+}
+
+func newOpenAI(s Settings) *openAI {
+	o := &openAI{
+		apiKey:  s.APIKey,
+		model:   s.Model,
+		baseURL: strings.TrimSuffix(s.BaseURL, "/"),
+		http:    &http.Client{Timeout: 60 * time.Second},
+	}
+	if o.model == "" {
+		o.model = openAIModel
+	}
+	if o.baseURL == "" {
+		o.baseURL = openAIBaseURL
+	}
+	return o
+}
+
+func (o *openAI) Name() string  { return "OpenAI" }
+func (o *openAI) Model() string { return o.model }
+
+// Ready requires a key only for OpenAI itself; self-hosted servers often
+// run without one.
+func (o *openAI) Ready() error {
+	if o.apiKey == "" && o.baseURL == openAIBaseURL {
+		return errors.New("no OpenAI API key configured (set api_key in ~/.config/grove/config.json or OPENAI_API_KEY)")
+	}
+	return nil
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIRequest struct {
+	Model    string          `json:"model"`
+	Messages []openAIMessage `json:"messages"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// chatMessages converts req to the role/content list that both OpenAI and
+// Ollama take, with the system instruction first.
+func chatMessages(req Request) []openAIMessage {
+	var out []openAIMessage
+	if req.System != "" {
+		out = append(out, openAIMessage{Role: "system", Content: req.System})
+	}
+	for _, m := range req.Messages {
+		out = append(out, openAIMessage{Role: string(m.Role), Content: m.Content})
+	}
+	return out
+}
+
+func (o *openAI) Generate(ctx context.Context, req Request) (string, error) {
+	if err := o.Ready(); err != nil {
+		return "", err
+	}
+	header := http.Header{}
+	if o.apiKey != "" {
+		header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+	body := openAIRequest{Model: o.model, Messages: chatMessages(req)}
+	data, status, err := postJSON(ctx, o.http, o.baseURL+"/chat/completions", header, body)
+	if err != nil {
+		return "", err
+	}
+
+	var result openAIResponse
+	if err := decodeResponse(data, status, &result); err != nil {
+		return "", err
+	}
+	if result.Error != nil {
+		return "", fmt.Errorf("API error: %s", result.Error.Message)
+	}
+	if err := statusError(status, data); err != nil {
+		return "", err
+	}
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("no response from API")
+	}
+	return result.Choices[0].Message.Content, nil
+}
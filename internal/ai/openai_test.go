@@ -0,0 +1,67 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenAI(t *testing.T) {
+	var got openAIRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if a := r.Header.Get("Authorization"); a != "Bearer sk-test" {
+			t.Errorf("Authorization = %q", a)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"42"}}]}`))
+	}))
+	defer srv.Close()
+
+	p, _ := NewProvider(Settings{Provider: "openai", APIKey: "sk-test", Model: "gpt-test", BaseURL: srv.URL + "/v1/"})
+	answer, err := p.Generate(context.Background(), Request{
+		System:   "be brief",
+		Messages: []Message{{Role: RoleUser, Content: "meaning of life?"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "42" {
+		t.Errorf("answer = %q", answer)
+	}
+	want := []openAIMessage{{Role: "system", Content: "be brief"}, {Role: "user", Content: "meaning of life?"}}
+	if got.Model != "gpt-test" || len(got.Messages) != 2 || got.Messages[0] != want[0] || got.Messages[1] != want[1] {
+		t.Errorf("request = %+v", got)
+	}
+}
+
+func TestOpenAI_errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Error("sent an Authorization header without a key")
+		}
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("upstream down"))
+	}))
+	defer srv.Close()
+
+	// A self-hosted gateway needs no key
+	p, _ := NewProvider(Settings{Provider: "openai", BaseURL: srv.URL})
+	if err := p.Ready(); err != nil {
+		t.Fatalf("Ready: %v", err)
+	}
+	_, err := p.Generate(context.Background(), Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}})
+	if err == nil || !strings.Contains(err.Error(), "HTTP 502: upstream down") {
+		t.Errorf("err = %v", err)
+	}
+
+	p, _ = NewProvider(Settings{Provider: "openai"})
+	if p.Ready() == nil {
+		t.Error("api.openai.com without a key should not be ready")
+	}
+}
@@ -0,0 +1,123 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Provider is a model API that grove can send prompts to.
+type Provider interface {
+	// Name is how the provider is shown to the user, e.g. "Gemini".
+	Name() string
+	// Model is the model requests go to, e.g. "gemini-2.5-flash".
+	Model() string
+	// Ready returns why the provider can't be used yet, such as a missing
+	// API key, or nil.
+	Ready() error
+	// Generate returns the model's reply to req.
+	Generate(ctx context.Context, req Request) (string, error)
+}
+
+// Request is a prompt for a Provider.
+type Request struct {
+	System   string    // system instruction; may be empty
+	Messages []Message // the conversation so far, oldest first
+}
+
+// Role says who wrote a Message.
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// Message is one turn of a conversation.
+type Message struct {
+	Role    Role   `json:"role"`
+	Content string `json:"content"`
+}
+
+// Settings selects and configures a provider.
+type Settings struct {
+	Provider string // "gemini" (default), "openai" or "ollama"
+	APIKey   string
+	Model    string // "" uses the provider's default
+	BaseURL  string // "" uses the provider's public endpoint
+}
+
+// Providers lists the names Settings.Provider accepts.
+var Providers = []string{"gemini", "openai", "ollama"}
+
+// NewProvider returns the provider s names.
+func NewProvider(s Settings) (Provider, error) {
+	switch strings.ToLower(s.Provider) {
+	case "", "gemini":
+		return newGemini(s), nil
+	case "openai":
+		return newOpenAI(s), nil
+	case "ollama":
+		return newOllama(s), nil
+	}
+	return nil, fmt.Errorf("unknown AI provider %q (want %s)", s.Provider, strings.Join(Providers, ", "))
+}
+
+// postJSON sends in as JSON to url and returns the response body and
+// status.
+func postJSON(ctx context.Context, client *http.Client, url string, header http.Header, in any) ([]byte, int, error) {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return nil, 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, resp.StatusCode, nil
+}
+
+// decodeResponse unmarshals data into out, reporting a failed HTTP status
+// rather than a parse error when the body isn't the JSON expected.
+func decodeResponse(data []byte, status int, out any) error {
+	if err := json.Unmarshal(data, out); err != nil {
+		if status >= 300 {
+			return fmt.Errorf("API error: HTTP %d: %s", status, snippet(data))
+		}
+		return fmt.Errorf("parse error: %w\nraw: %s", err, string(data))
+	}
+	return nil
+}
+
+// statusError reports a failed HTTP status whose body carried no message.
+func statusError(status int, data []byte) error {
+	if status >= 300 {
+		return fmt.Errorf("API error: HTTP %d: %s", status, snippet(data))
+	}
+	return nil
+}
+
+func snippet(data []byte) string {
+	s := strings.TrimSpace(string(data))
+	if len(s) > 200 {
+		s = s[:200] + "..."
+	}
+	return s
+}
@@ -0,0 +1,109 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Streamer is a Provider that can deliver its reply in pieces as the model
+// writes it.
+type Streamer interface {
+	Provider
+	// Stream calls chunk with each piece of the reply to req, in order.
+	Stream(ctx context.Context, req Request, chunk func(string)) error
+}
+
+// streamIdle is how long a stream may go without the server sending
+// anything, headers included, before it is given up on.
+var streamIdle = 60 * time.Second
+
+// streamClient has no overall timeout, since a long answer may stream for
+// minutes; only a server that stops sending is cut off.
+var streamClient = newStreamClient(streamIdle)
+
+func newStreamClient(idle time.Duration) *http.Client {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.ResponseHeaderTimeout = idle
+	return &http.Client{Transport: t}
+}
+
+// doStream sends req with streamClient. Reading the response body fails if
+// the server then sends nothing for streamIdle.
+func doStream(req *http.Request) (*http.Response, error) {
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	b := &idleBody{ReadCloser: resp.Body, idle: streamIdle}
+	b.timer = time.AfterFunc(b.idle, func() {
+		b.stalled.Store(true)
+		b.ReadCloser.Close()
+	})
+	resp.Body = b
+	return resp, nil
+}
+
+// idleBody closes a response body that has been silent for too long.
+type idleBody struct {
+	io.ReadCloser
+	idle    time.Duration
+	timer   *time.Timer
+	stalled atomic.Bool
+}
+
+func (b *idleBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if b.stalled.Load() {
+		return n, fmt.Errorf("the API sent nothing for %s", b.idle)
+	}
+	if n > 0 {
+		b.timer.Reset(b.idle)
+	}
+	return n, err
+}
+
+func (b *idleBody) Close() error {
+	b.timer.Stop()
+	return b.ReadCloser.Close()
+}
+
+// readSSE calls event with the data of each server-sent event in r.
+func readSSE(r io.Reader, event func(data []byte) error) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	var data bytes.Buffer
+	flush := func() error {
+		if data.Len() == 0 {
+			return nil
+		}
+		err := event(data.Bytes())
+		data.Reset()
+		return err
+	}
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case line == "":
+			if err := flush(); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+		// Comments, event names and ids aren't used by any provider
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
@@ -0,0 +1,109 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxTagSuggestions is how many tags SuggestTags returns at most.
+const MaxTagSuggestions = 5
+
+// tagNoteChars is how much of a note SuggestTags sends: enough to see what
+// it's about without paying for all of a long one.
+const tagNoteChars = 8000
+
+// maxVocabulary is how many of the vault's tags go in the prompt.
+const maxVocabulary = 200
+
+// TagSuggestion is a tag proposed for a note.
+type TagSuggestion struct {
+	Tag   string
+	Known bool // already used in the vault
+}
+
+// SuggestTags asks for tags for the note titled title, best first. vocab
+// is the vault's tags, most used first; the model is asked to prefer them,
+// and they are ranked ahead of new ones. Tags the note already has are
+// left out.
+func (c *Client) SuggestTags(ctx context.Context, title, body string, have, vocab []string) ([]TagSuggestion, error) {
+	if err := c.Ready(); err != nil {
+		return nil, err
+	}
+	body = clip(body, tagNoteChars)
+	known := vocab
+	if len(known) > maxVocabulary {
+		known = known[:maxVocabulary]
+	}
+	vocabText := strings.Join(known, ", ")
+	if vocabText == "" {
+		vocabText = "(none yet)"
+	}
+	haveText := strings.Join(have, ", ")
+	if haveText == "" {
+		haveText = "(none)"
+	}
+
+	prompt := fmt.Sprintf(
+		"Suggest up to %d tags for this note, best first. Strongly prefer tags from the vault's existing tags, which are listed most used first; only suggest a new tag if none of them fit. Tags are lowercase, with hyphens instead of spaces. Reply with one tag per line and nothing else.\n\nEXISTING TAGS: %s\nTHE NOTE'S TAGS: %s\n\nNOTE: %s\n\n%s",
+		MaxTagSuggestions, vocabText, haveText, title, body,
+	)
+	reply, err := c.send(ctx, Request{
+		Messages: []Message{{Role: RoleUser, Content: prompt}},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseTagSuggestions(reply, have, vocab), nil
+}
+
+// tagJunkRe matches a list marker a model may put before a tag.
+var tagJunkRe = regexp.MustCompile(`^(?:[-*+•]|\d+[.)])\s+`)
+
+// parseTagSuggestions reads one tag per line from reply. Tags the vault
+// knows take its spelling and come first; otherwise the model's order is
+// kept.
+func parseTagSuggestions(reply string, have, vocab []string) []TagSuggestion {
+	spelling := map[string]string{}
+	for _, t := range vocab {
+		spelling[strings.ToLower(t)] = t
+	}
+	seen := map[string]bool{}
+	for _, t := range have {
+		seen[strings.ToLower(t)] = true
+	}
+
+	var out []TagSuggestion
+	for _, line := range strings.Split(reply, "\n") {
+		line = tagJunkRe.ReplaceAllString(strings.TrimSpace(line), "")
+		tag := strings.ToLower(strings.Join(strings.Fields(strings.Trim(line, " ,.#`*")), "-"))
+		if tag == "" || strings.ContainsAny(tag, ":,[]{}") || len(tag) > 40 || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		s := TagSuggestion{Tag: tag}
+		if known, ok := spelling[tag]; ok {
+			s = TagSuggestion{Tag: known, Known: true}
+		}
+		out = append(out, s)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Known && !out[j].Known })
+	if len(out) > MaxTagSuggestions {
+		out = out[:MaxTagSuggestions]
+	}
+	return out
+}
+
+// clip cuts s to at most n bytes at a character boundary, marking the cut.
+func clip(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "…"
+}
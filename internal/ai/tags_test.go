@@ -0,0 +1,69 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseTagSuggestions(t *testing.T) {
+	reply := "1. Project Planning\n- #Work\n* `ideas`\nwork\nmeeting\nnot: a tag\n\natlas"
+	got := parseTagSuggestions(reply, []string{"atlas"}, []string{"work", "Ideas", "meeting"})
+	want := []TagSuggestion{
+		{Tag: "work", Known: true},
+		{Tag: "Ideas", Known: true},
+		{Tag: "meeting", Known: true},
+		{Tag: "project-planning"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTagSuggestions = %+v, want %+v", got, want)
+	}
+}
+
+func TestSuggestTags(t *testing.T) {
+	var req ollamaRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.Write([]byte(`{"message":{"role":"assistant","content":"work\nnew-thing\n"}}`))
+	}))
+	defer srv.Close()
+
+	p, _ := NewProvider(Settings{Provider: "ollama", BaseURL: srv.URL})
+	got, err := NewClient(p).SuggestTags(context.Background(), "Kickoff", strings.Repeat("é", tagNoteChars), nil, []string{"work", "ideas"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || !got[0].Known || got[1].Tag != "new-thing" {
+		t.Errorf("suggestions = %+v", got)
+	}
+	prompt := req.Messages[0].Content
+	if !utf8.ValidString(prompt) {
+		t.Error("long note cut mid-character")
+	}
+	if !strings.Contains(prompt, "EXISTING TAGS: work, ideas") || !strings.Contains(prompt, "NOTE: Kickoff") {
+		t.Errorf("prompt:\n%s", prompt)
+	}
+}
+
+func TestClip(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"héllo", 2, "h…"},
+		{"a\xffbcdef", 4, "a\xffbc…"},
+		{"日本語", 4, "日…"},
+	}
+	for _, tt := range tests {
+		if got := clip(tt.s, tt.n); got != tt.want {
+			t.Errorf("clip(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
@@ -1,17 +1,37 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 type Config struct {
-	NotesDir   string `json:"notes_dir"`
-	Editor     string `json:"editor"`
-	AIEnabled  bool   `json:"ai_enabled"`
-	GeminiKey  string `json:"api_key"`
-	GeminiModel string `json:"model"`
+	NotesDir  string `json:"notes_dir"`
+	DataDir   string `json:"data_dir"`
+	CacheDir  string `json:"cache_dir"`
+	Editor    string `json:"editor"`
+	AIEnabled bool   `json:"ai_enabled"`
+
+	// AI provider: "gemini" (the default), "openai" or "ollama". Model and
+	// BaseURL fall back to the provider's defaults when empty.
+	Provider string `json:"provider"`
+	APIKey   string `json:"api_key"`
+	Model    string `json:"model"`
+	BaseURL  string `json:"base_url"`
+
+	SavedSearches []SavedSearch `json:"saved_searches,omitempty"`
+}
+
+// SavedSearch is a named query, run with `grove search @name` and listed in
+// the TUI sidebar.
+type SavedSearch struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
 }
 
 type PairyConfig struct {
@@ -21,10 +41,11 @@ type PairyConfig struct {
 
 func Load() (*Config, error) {
 	cfg := &Config{
-		NotesDir:    defaultNotesDir(),
-		Editor:      defaultEditor(),
-		AIEnabled:   true,
-		GeminiModel: "gemini-2.5-flash",
+		NotesDir:  defaultNotesDir(),
+		DataDir:   defaultDataDir(),
+		CacheDir:  defaultCacheDir(),
+		Editor:    defaultEditor(),
+		AIEnabled: true,
 	}
 
 	// Load grove config if exists
@@ -33,23 +54,31 @@ func Load() (*Config, error) {
 		_ = json.Unmarshal(data, cfg)
 	}
 
-	// Fallback: load Gemini key from pairy config
-	if cfg.GeminiKey == "" {
-		pairyConfigPath := filepath.Join(xdgConfig(), "pairy", "config.json")
-		if data, err := os.ReadFile(pairyConfigPath); err == nil {
-			var pc PairyConfig
-			if err := json.Unmarshal(data, &pc); err == nil {
-				cfg.GeminiKey = pc.APIKey
-				if pc.Model != "" {
-					cfg.GeminiModel = pc.Model
+	switch strings.ToLower(cfg.Provider) {
+	case "", "gemini":
+		// Fallback: load Gemini key from pairy config
+		if cfg.APIKey == "" {
+			pairyConfigPath := filepath.Join(xdgConfig(), "pairy", "config.json")
+			if data, err := os.ReadFile(pairyConfigPath); err == nil {
+				var pc PairyConfig
+				if err := json.Unmarshal(data, &pc); err == nil {
+					cfg.APIKey = pc.APIKey
+					if cfg.Model == "" {
+						cfg.Model = pc.Model
+					}
 				}
 			}
 		}
-	}
 
-	// Also check GEMINI_API_KEY env
-	if cfg.GeminiKey == "" {
-		cfg.GeminiKey = os.Getenv("GEMINI_API_KEY")
+		// Also check GEMINI_API_KEY env
+		if cfg.APIKey == "" {
+			cfg.APIKey = os.Getenv("GEMINI_API_KEY")
+		}
+
+	case "openai":
+		if cfg.APIKey == "" {
+			cfg.APIKey = os.Getenv("OPENAI_API_KEY")
+		}
 	}
 
 	// Ensure notes dir exists
@@ -72,6 +101,88 @@ func Save(cfg *Config) error {
 	return os.WriteFile(filepath.Join(dir, "config.json"), data, 0644)
 }
 
+// SessionFile is where grove ask keeps the conversation that
+// grove ask --continue carries on. Like the AI log, each vault has its own;
+// with no DataDir there is none.
+func (c *Config) SessionFile() string {
+	if c.DataDir == "" {
+		return ""
+	}
+	return filepath.Join(c.DataDir, "ask-session-"+c.vaultKey()+".json")
+}
+
+// AILogFile is where grove keeps the questions asked of the AI about this
+// vault and its notes. Each vault gets its own log, named after its path;
+// with no DataDir there is none.
+func (c *Config) AILogFile() string {
+	if c.DataDir == "" {
+		return ""
+	}
+	return filepath.Join(c.DataDir, "ai-log-"+c.vaultKey()+".jsonl")
+}
+
+// vaultKey names files kept per vault, from a hash of its path.
+func (c *Config) vaultKey() string {
+	sum := sha256.Sum256([]byte(c.NotesDir))
+	return hex.EncodeToString(sum[:8])
+}
+
+// SavedSearch returns the query saved under name.
+func (c *Config) SavedSearch(name string) (string, bool) {
+	for _, s := range c.SavedSearches {
+		if s.Name == name {
+			return s.Query, true
+		}
+	}
+	return "", false
+}
+
+// SaveSearch stores query under name, replacing any existing search with
+// that name, and writes it to the grove config file. Only the
+// saved_searches key is rewritten, so values that came from pairy's config
+// or the environment are not copied into grove's.
+func (c *Config) SaveSearch(name, query string) error {
+	replaced := false
+	for i := range c.SavedSearches {
+		if c.SavedSearches[i].Name == name {
+			c.SavedSearches[i].Query = query
+			replaced = true
+		}
+	}
+	if !replaced {
+		c.SavedSearches = append(c.SavedSearches, SavedSearch{Name: name, Query: query})
+	}
+	return updateConfigFile("saved_searches", c.SavedSearches)
+}
+
+// updateConfigFile sets one top-level key in the grove config file, leaving
+// the rest of the file as it was.
+func updateConfigFile(key string, value any) error {
+	dir := filepath.Join(xdgConfig(), "grove")
+	path := filepath.Join(dir, "config.json")
+
+	raw := map[string]json.RawMessage{}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	v, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	raw[key] = v
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
 func xdgConfig() string {
 	if d := os.Getenv("XDG_CONFIG_HOME"); d != "" {
 		return d
@@ -81,11 +192,26 @@ func xdgConfig() string {
 }
 
 func defaultNotesDir() string {
+	return filepath.Join(defaultDataDir(), "notes")
+}
+
+// defaultDataDir holds grove's own state that isn't notes, like the
+// grove ask session.
+func defaultDataDir() string {
 	if d := os.Getenv("XDG_DATA_HOME"); d != "" {
-		return filepath.Join(d, "grove", "notes")
+		return filepath.Join(d, "grove")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".local", "share", "grove")
+}
+
+// defaultCacheDir holds data grove can always rebuild, like the note index.
+func defaultCacheDir() string {
+	if d := os.Getenv("XDG_CACHE_HOME"); d != "" {
+		return filepath.Join(d, "grove")
 	}
 	home, _ := os.UserHomeDir()
-	return filepath.Join(home, ".local", "share", "grove", "notes")
+	return filepath.Join(home, ".cache", "grove")
 }
 
 func defaultEditor() string {
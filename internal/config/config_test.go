@@ -0,0 +1,57 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveSearch_keepsOtherKeys(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	path := filepath.Join(dir, "grove", "config.json")
+	_ = os.MkdirAll(filepath.Dir(path), 0755)
+	_ = os.WriteFile(path, []byte(`{"editor": "nvim", "custom": 42}`), 0644)
+
+	cfg := &Config{APIKey: "from-env"}
+	if err := cfg.SaveSearch("todo", "has:todo"); err != nil {
+		t.Fatalf("SaveSearch: %v", err)
+	}
+	if err := cfg.SaveSearch("todo", "has:todo tag:work"); err != nil {
+		t.Fatalf("SaveSearch replace: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("config not valid JSON: %v", err)
+	}
+	if raw["editor"] != "nvim" || raw["custom"] != float64(42) {
+		t.Errorf("other keys lost: %s", data)
+	}
+	if _, ok := raw["api_key"]; ok {
+		t.Errorf("api key should not be written: %s", data)
+	}
+
+	if q, ok := cfg.SavedSearch("todo"); !ok || q != "has:todo tag:work" {
+		t.Errorf("SavedSearch: got %q, %v", q, ok)
+	}
+	if len(cfg.SavedSearches) != 1 {
+		t.Errorf("replacing should not duplicate: %v", cfg.SavedSearches)
+	}
+}
+
+func TestSessionFile_perVault(t *testing.T) {
+	a := &Config{NotesDir: "/notes/work", DataDir: "/data"}
+	b := &Config{NotesDir: "/notes/home", DataDir: "/data"}
+	if a.SessionFile() == b.SessionFile() {
+		t.Errorf("vaults share a session file: %s", a.SessionFile())
+	}
+	if filepath.Dir(a.SessionFile()) != "/data" {
+		t.Errorf("session file outside DataDir: %s", a.SessionFile())
+	}
+	if f := (&Config{NotesDir: "/notes/work"}).SessionFile(); f != "" {
+		t.Errorf("no DataDir should mean no session file, got %s", f)
+	}
+}
@@ -0,0 +1,63 @@
+package notes
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Hooks for tests to simulate failures partway through a write.
+var (
+	writeTemp = func(f *os.File, data []byte) (int, error) { return f.Write(data) }
+	syncTemp  = func(f *os.File) error { return f.Sync() }
+)
+
+// writeFileAtomic replaces path with data without ever leaving a partially
+// written file behind: the content goes to a temp file in the same
+// directory, is fsynced, and is then renamed over the original. An existing
+// file keeps its mode; new files get perm.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, ".grove-tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	// Clean up the temp file on any failure below.
+	ok := false
+	defer func() {
+		if !ok {
+			_ = tmp.Close()
+			_ = os.Remove(tmpName)
+		}
+	}()
+
+	if _, err := writeTemp(tmp, data); err != nil {
+		return err
+	}
+	if err := syncTemp(tmp); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		return err
+	}
+	ok = true
+
+	// Persist the rename itself. Not every platform supports syncing a
+	// directory, so this is best effort.
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		_ = d.Close()
+	}
+	return nil
+}
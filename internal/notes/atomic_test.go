@@ -0,0 +1,90 @@
+package notes
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteFileAtomic_keepsMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "note.md")
+	_ = os.WriteFile(path, []byte("old"), 0600)
+
+	if err := writeFileAtomic(path, []byte("new"), 0644); err != nil {
+		t.Fatalf("writeFileAtomic: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "new" {
+		t.Errorf("content: got %q", data)
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode: got %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestStore_Save_failedWriteKeepsOldContent(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(f *os.File, data []byte) (int, error)
+		sync  func(f *os.File) error
+	}{
+		{
+			name: "disk full halfway",
+			write: func(f *os.File, data []byte) (int, error) {
+				n, _ := f.Write(data[:len(data)/2])
+				return n, errors.New("no space left on device")
+			},
+			sync: syncTemp,
+		},
+		{
+			name:  "fsync fails",
+			write: writeTemp,
+			sync:  func(f *os.File) error { return errors.New("input/output error") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s := NewStore(dir)
+			note, err := s.Create("Precious", nil)
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			note.Body = "original body"
+			if err := s.Save(note); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			before, _ := os.ReadFile(note.Filename)
+
+			origWrite, origSync := writeTemp, syncTemp
+			writeTemp, syncTemp = tt.write, tt.sync
+			defer func() { writeTemp, syncTemp = origWrite, origSync }()
+
+			note.Body = strings.Repeat("replacement ", 1000)
+			if err := s.Save(note); err == nil {
+				t.Fatal("expected Save to fail")
+			}
+
+			after, _ := os.ReadFile(note.Filename)
+			if string(after) != string(before) {
+				t.Errorf("original content was clobbered:\n got %q\nwant %q", after, before)
+			}
+			if note.Raw != string(before) {
+				t.Errorf("Raw should still reflect what is on disk after a failed save")
+			}
+
+			entries, _ := os.ReadDir(dir)
+			if len(entries) != 1 {
+				var names []string
+				for _, e := range entries {
+					names = append(names, e.Name())
+				}
+				t.Errorf("temp file left behind: %v", names)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package notes
+
+import "strings"
+
+// AppendSection adds text to the end of the level-2 section titled heading
+// in body, starting the section at the end of body if there isn't one.
+// Headings inside fenced code don't count.
+func AppendSection(body, heading, text string) string {
+	text = strings.Trim(text, "\n")
+	lines := strings.Split(body, "\n")
+	levels := headingLevels(lines)
+	for i, line := range lines {
+		if levels[i] != 2 || !strings.EqualFold(strings.TrimSpace(line[2:]), heading) {
+			continue
+		}
+		end := len(lines)
+		for j := i + 1; j < len(lines); j++ {
+			if lv := levels[j]; lv > 0 && lv <= 2 {
+				end = j
+				break
+			}
+		}
+		before := strings.TrimRight(strings.Join(lines[:end], "\n"), "\n")
+		if end == len(lines) {
+			return before + "\n\n" + text + "\n"
+		}
+		return before + "\n\n" + text + "\n\n" + strings.Join(lines[end:], "\n")
+	}
+	body = strings.TrimRight(body, "\n")
+	if body != "" {
+		body += "\n\n"
+	}
+	return body + "## " + heading + "\n\n" + text + "\n"
+}
+
+// DemoteHeadings shifts the headings in text down so the highest is at
+// level top, for nesting text under a heading of its own. Headings that
+// would go past level 6 stay at 6.
+func DemoteHeadings(text string, top int) string {
+	lines := strings.Split(text, "\n")
+	levels := headingLevels(lines)
+	highest := 0
+	for _, lv := range levels {
+		if lv > 0 && (highest == 0 || lv < highest) {
+			highest = lv
+		}
+	}
+	if highest == 0 || highest >= top {
+		return text
+	}
+	for i, lv := range levels {
+		if lv > 0 {
+			lines[i] = strings.Repeat("#", min(lv+top-highest, 6)) + lines[i][lv:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// headingLevels returns headingLevel for each line, with 0 for lines in
+// fenced code blocks.
+func headingLevels(lines []string) []int {
+	levels := make([]int, len(lines))
+	fence := ""
+	for i, line := range lines {
+		t := strings.TrimLeft(line, " ")
+		switch {
+		case fence != "":
+			if strings.HasPrefix(t, fence) {
+				fence = ""
+			}
+		case strings.HasPrefix(t, "```"):
+			fence = "```"
+		case strings.HasPrefix(t, "~~~"):
+			fence = "~~~"
+		default:
+			levels[i] = headingLevel(line)
+		}
+	}
+	return levels
+}
+
+// headingLevel returns the level of a markdown heading line, or 0.
+func headingLevel(line string) int {
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	if level == 0 || level > 6 || (len(line) > level && line[level] != ' ') {
+		return 0
+	}
+	return level
+}
+
+func isListItem(line string) bool {
+	t := strings.TrimLeft(line, " \t")
+	if strings.HasPrefix(t, "- ") || strings.HasPrefix(t, "* ") || strings.HasPrefix(t, "+ ") {
+		return true
+	}
+	digits := len(t) - len(strings.TrimLeft(t, "0123456789"))
+	return digits > 0 && strings.HasPrefix(t[digits:], ". ")
+}
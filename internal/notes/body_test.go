@@ -0,0 +1,40 @@
+package notes
+
+import "testing"
+
+func TestAppendSection(t *testing.T) {
+	tests := []struct {
+		body, want string
+	}{
+		{"", "## AI\n\nnew\n"},
+		{"intro\n", "intro\n\n## AI\n\nnew\n"},
+		{"intro\n\n## AI\n\nold\n", "intro\n\n## AI\n\nold\n\nnew\n"},
+		{"## ai\n\nold\n### Sub\n\nx\n\n## Next\n\nmore", "## ai\n\nold\n### Sub\n\nx\n\nnew\n\n## Next\n\nmore"},
+		{"## AI\n\n```sh\n## not a heading\n```\n\nold\n", "## AI\n\n```sh\n## not a heading\n```\n\nold\n\nnew\n"},
+		{"```\n## AI\n```\n", "```\n## AI\n```\n\n## AI\n\nnew\n"},
+	}
+	for _, tt := range tests {
+		if got := AppendSection(tt.body, "AI", "new"); got != tt.want {
+			t.Errorf("AppendSection(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestDemoteHeadings(t *testing.T) {
+	tests := []struct {
+		text string
+		top  int
+		want string
+	}{
+		{"# A\n\ntext\n## B\n###### C", 4, "#### A\n\ntext\n##### B\n###### C"},
+		{"## A\n```\n# code\n```", 4, "#### A\n```\n# code\n```"},
+		{"#### A\n## B", 4, "###### A\n#### B"},
+		{"##### A", 4, "##### A"},
+		{"no headings, #hashtag", 4, "no headings, #hashtag"},
+	}
+	for _, tt := range tests {
+		if got := DemoteHeadings(tt.text, tt.top); got != tt.want {
+			t.Errorf("DemoteHeadings(%q, %d) = %q, want %q", tt.text, tt.top, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package notes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConflictError is returned by Store.Save when the note's file was changed
+// on disk after the note was loaded — by `grove add` in another shell, a
+// sync tool, or another editor. Nothing is written when it is returned.
+type ConflictError struct {
+	Note *Note // the in-memory version that was being saved
+	Disk *Note // the version currently on disk
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s changed on disk since it was loaded", e.Note.ID)
+}
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Merge combines the bodies of an in-memory note and the conflicting version
+// on disk. If only one side changed since the note was loaded, that side
+// wins outright. Otherwise lines the two share at the start and end are
+// kept and the differing middle is wrapped in git-style conflict markers
+// for the user to resolve in their editor. The second result reports
+// whether markers were needed.
+func Merge(mine, disk *Note) (string, bool) {
+	_, base, _ := ParseFrontmatter(mine.Raw)
+	switch {
+	case mine.Body == disk.Body:
+		return mine.Body, false
+	case mine.Body == base:
+		return disk.Body, false
+	case disk.Body == base:
+		return mine.Body, false
+	}
+
+	a := strings.Split(mine.Body, "\n")
+	b := strings.Split(disk.Body, "\n")
+
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	var out []string
+	out = append(out, a[:pre]...)
+	out = append(out, "<<<<<<< grove (yours)")
+	out = append(out, a[pre:len(a)-suf]...)
+	out = append(out, "=======")
+	out = append(out, b[pre:len(b)-suf]...)
+	out = append(out, ">>>>>>> disk")
+	out = append(out, a[len(a)-suf:]...)
+	return strings.Join(out, "\n"), true
+}
+
+// MergeFront merges the frontmatter of the conflicting version on disk
+// into mine, against the version mine was loaded from: a field mine
+// hasn't changed since then takes the disk value. It reports false, and
+// leaves mine alone, if both sides changed the same field differently.
+func MergeFront(mine, disk *Note) bool {
+	base := NoteFromRaw(mine.ID, mine.Filename, mine.Raw, mine.Created)
+
+	title, ok := merge3(base.Title, mine.Title, disk.Title)
+	if !ok {
+		return false
+	}
+	tags, ok := merge3(strings.Join(base.Tags, "\n"), strings.Join(mine.Tags, "\n"), strings.Join(disk.Tags, "\n"))
+	if !ok {
+		return false
+	}
+	stamp := func(n *Note) string { return n.Created.UTC().Format(time.RFC3339) }
+	created, ok := merge3(stamp(base), stamp(mine), stamp(disk))
+	if !ok {
+		return false
+	}
+
+	var keys []string
+	for _, fm := range []Frontmatter{mine.Extra, disk.Extra, base.Extra} {
+		for _, f := range fm {
+			if !slices.Contains(keys, f.Key) {
+				keys = append(keys, f.Key)
+			}
+		}
+	}
+	var extra Frontmatter
+	for _, key := range keys {
+		b, m, d := base.Extra.field(key), mine.Extra.field(key), disk.Extra.field(key)
+		text, ok := merge3(b.text(), m.text(), d.text())
+		if !ok {
+			return false
+		}
+		switch {
+		case text == "":
+		case text == m.text():
+			extra = append(extra, *m)
+		default:
+			extra = append(extra, *d)
+		}
+	}
+
+	mine.Title = title
+	if tags != strings.Join(mine.Tags, "\n") {
+		mine.Tags = disk.Tags
+	}
+	if created != stamp(mine) {
+		mine.Created = disk.Created
+	}
+	mine.front = disk.front
+	mine.frontErr = disk.frontErr
+	mine.Extra = extra
+	mine.Aliases = listFrom(extra.Get("aliases"))
+	return true
+}
+
+// merge3 picks the merged value of a field from its base, mine and disk
+// versions. ok is false if mine and disk changed it differently.
+func merge3[T comparable](base, mine, disk T) (T, bool) {
+	switch {
+	case mine == base || mine == disk:
+		return disk, true
+	case disk == base:
+		return mine, true
+	}
+	return mine, false
+}
+
+// field returns the field key, or nil.
+func (fm Frontmatter) field(key string) *Field {
+	for i := range fm {
+		if fm[i].Key == key {
+			return &fm[i]
+		}
+	}
+	return nil
+}
+
+// text is f's value as YAML, for comparing versions; "" if f is nil.
+func (f *Field) text() string {
+	if f == nil {
+		return ""
+	}
+	out, err := yaml.Marshal(f.Value)
+	if err != nil {
+		return f.Value.Value
+	}
+	return string(out)
+}
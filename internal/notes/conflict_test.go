@@ -0,0 +1,121 @@
+package notes
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestStore_Save_detectsExternalEdit(t *testing.T) {
+	s := NewStore(t.TempDir())
+	note, _ := s.Create("Shared", nil)
+
+	loaded, err := s.Load(note.ID)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	// Someone else appends to the file after we loaded it.
+	f, _ := os.OpenFile(note.Filename, os.O_APPEND|os.O_WRONLY, 0644)
+	_, _ = f.WriteString("\n- added from another shell\n")
+	f.Close()
+	external, _ := os.ReadFile(note.Filename)
+
+	loaded.Body = "my edit"
+	err = s.Save(loaded)
+	var ce *ConflictError
+	if !errors.As(err, &ce) {
+		t.Fatalf("expected *ConflictError, got %v", err)
+	}
+	if !strings.Contains(ce.Disk.Body, "added from another shell") {
+		t.Errorf("conflict Disk should hold the external version, got %q", ce.Disk.Body)
+	}
+	if data, _ := os.ReadFile(note.Filename); string(data) != string(external) {
+		t.Error("Save must not write when it reports a conflict")
+	}
+
+	if err := s.Overwrite(loaded); err != nil {
+		t.Fatalf("Overwrite: %v", err)
+	}
+	// After overwriting, further saves of the same value succeed.
+	loaded.Body = "second edit"
+	if err := s.Save(loaded); err != nil {
+		t.Errorf("Save after Overwrite: %v", err)
+	}
+}
+
+func TestStore_Save_noConflictWhenUnchanged(t *testing.T) {
+	s := NewStore(t.TempDir())
+	note, _ := s.Create("Solo", nil)
+	loaded, _ := s.Load(note.ID)
+
+	loaded.Body = "one"
+	if err := s.Save(loaded); err != nil {
+		t.Fatalf("first Save: %v", err)
+	}
+	loaded.Body = "two"
+	if err := s.Save(loaded); err != nil {
+		t.Fatalf("second Save: %v", err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := "---\ntitle: T\n---\n\nline one\nline two"
+	mk := func(body string) *Note { return &Note{Raw: base, Body: body} }
+
+	t.Run("only disk changed", func(t *testing.T) {
+		got, conflict := Merge(mk("line one\nline two"), mk("line one\nline two\nline three"))
+		if conflict || got != "line one\nline two\nline three" {
+			t.Errorf("got %q conflict=%v", got, conflict)
+		}
+	})
+
+	t.Run("only mine changed", func(t *testing.T) {
+		got, conflict := Merge(mk("line one\nedited"), mk("line one\nline two"))
+		if conflict || got != "line one\nedited" {
+			t.Errorf("got %q conflict=%v", got, conflict)
+		}
+	})
+
+	t.Run("both changed", func(t *testing.T) {
+		got, conflict := Merge(mk("line one\nmine\nend"), mk("line one\ntheirs\nend"))
+		want := "line one\n<<<<<<< grove (yours)\nmine\n=======\ntheirs\n>>>>>>> disk\nend"
+		if !conflict || got != want {
+			t.Errorf("got %q conflict=%v", got, conflict)
+		}
+	})
+}
+
+func TestMergeFront(t *testing.T) {
+	base := "---\ntitle: Plan\ntags: [work]\ncreated: 2026-01-01T00:00:00Z\nstatus: draft\n---\n\nbody"
+	load := func(raw string) *Note { return NoteFromRaw("plan", "plan.md", raw, time.Time{}) }
+
+	// Disk retagged and set a status; mine renamed and added a field
+	mine := load(base)
+	mine.Title = "Q3 plan"
+	mine.Extra.Set("owner", &yaml.Node{Kind: yaml.ScalarNode, Value: "sam"})
+	disk := load(strings.Replace(strings.Replace(base, "[work]", "[work, atlas]", 1), "draft", "done", 1))
+	if !MergeFront(mine, disk) {
+		t.Fatal("expected a clean merge")
+	}
+	got := BuildFrontmatter(mine)
+	for _, want := range []string{"title: Q3 plan\n", "tags: [work, atlas]\n", "status: done\n", "owner: sam\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("merged frontmatter lacks %q:\n%s", want, got)
+		}
+	}
+
+	// Both changed the status
+	mine = load(base)
+	mine.Extra.Set("status", &yaml.Node{Kind: yaml.ScalarNode, Value: "blocked"})
+	if MergeFront(mine, disk) {
+		t.Error("expected a conflict on status")
+	}
+	if mine.Extra.Scalar("status") != "blocked" {
+		t.Error("a failed merge should leave mine alone")
+	}
+}
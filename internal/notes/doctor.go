@@ -0,0 +1,201 @@
+package notes
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ProblemKind is a kind of problem Check reports.
+type ProblemKind int
+
+const (
+	BrokenLink ProblemKind = iota
+	Orphan
+	DuplicateTitle
+	AliasClash
+	BadFrontmatter
+	BadTimestamp
+)
+
+func (k ProblemKind) String() string {
+	switch k {
+	case BrokenLink:
+		return "broken links"
+	case Orphan:
+		return "orphans"
+	case DuplicateTitle:
+		return "duplicate titles"
+	case AliasClash:
+		return "alias clashes"
+	case BadFrontmatter:
+		return "unparseable frontmatter"
+	case BadTimestamp:
+		return "invalid timestamps"
+	}
+	return "problems"
+}
+
+// Problem is one thing Check found wrong with the vault.
+type Problem struct {
+	Kind   ProblemKind
+	Note   *Note   // the note the problem is in
+	Link   Link    // the broken link, for BrokenLink
+	Target *Note   // the note a BrokenLink points at; nil if it doesn't exist
+	Others []*Note // the other notes involved, for DuplicateTitle and AliasClash
+	Detail string  // what is wrong, for display
+}
+
+// Check scans all for broken wiki-links (to missing notes, headings or
+// blocks), orphans with no links in or out, duplicate titles, aliases
+// claimed twice, frontmatter that isn't valid YAML, and timestamps that
+// aren't RFC 3339. Problems are ordered by kind, then by note ID.
+func Check(all []*Note) []Problem {
+	r := NewResolver(all)
+	var out []Problem
+
+	linked := map[*Note]bool{}
+	for _, n := range all {
+		for _, l := range n.Links {
+			target := n
+			if l.Target != "" {
+				target = r.Resolve(l.Target)
+			}
+			switch {
+			case target == nil:
+				out = append(out, Problem{Kind: BrokenLink, Note: n, Link: l, Detail: "no note called " + l.Target})
+			case l.Heading != "" && !hasHeading(target.Body, l.Heading):
+				out = append(out, Problem{Kind: BrokenLink, Note: n, Link: l, Target: target, Detail: "no heading " + l.Heading + " in " + target.Title})
+			case l.Block != "" && !hasBlock(target.Body, l.Block):
+				out = append(out, Problem{Kind: BrokenLink, Note: n, Link: l, Target: target, Detail: "no block ^" + l.Block + " in " + target.Title})
+			}
+			if target != nil && target != n {
+				linked[n] = true
+				linked[target] = true
+			}
+		}
+	}
+	for _, n := range all {
+		if !linked[n] {
+			out = append(out, Problem{Kind: Orphan, Note: n, Detail: "no links in or out"})
+		}
+	}
+
+	byTitle := map[string][]*Note{}
+	for _, n := range all {
+		key := strings.ToLower(n.Title)
+		byTitle[key] = append(byTitle[key], n)
+	}
+	for _, ns := range byTitle {
+		if len(ns) > 1 {
+			sort.Slice(ns, func(i, j int) bool { return ns[i].ID < ns[j].ID })
+			out = append(out, Problem{Kind: DuplicateTitle, Note: ns[0], Others: ns[1:], Detail: fmt.Sprintf("%d notes titled %q", len(ns), ns[0].Title)})
+		}
+	}
+	for _, c := range r.Conflicts {
+		out = append(out, Problem{Kind: AliasClash, Note: c.Notes[0], Others: c.Notes[1:], Detail: fmt.Sprintf("alias %q is claimed by %d notes", c.Alias, len(c.Notes))})
+	}
+
+	for _, n := range all {
+		fm, _, err := ParseFrontmatter(n.Raw)
+		if err != nil {
+			out = append(out, Problem{Kind: BadFrontmatter, Note: n, Detail: err.Error()})
+		}
+		for _, key := range []string{"created", "updated"} {
+			v := fm.Scalar(key)
+			if v == "" {
+				continue
+			}
+			if _, err := time.Parse(time.RFC3339, v); err != nil {
+				out = append(out, Problem{Kind: BadTimestamp, Note: n, Detail: fmt.Sprintf("%s: %q is not YYYY-MM-DDTHH:MM:SSZ", key, v)})
+			}
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		return out[i].Note.ID < out[j].Note.ID
+	})
+	return out
+}
+
+// DanglingTarget is a link target no note answers to.
+type DanglingTarget struct {
+	Title string
+	From  []*Note // notes linking to it
+}
+
+// Dangling collects the targets of broken links to notes that don't exist,
+// sorted by title. Targets differing only in case are one target.
+func Dangling(problems []Problem) []DanglingTarget {
+	var out []DanglingTarget
+	at := map[string]int{} // lowercased title -> index in out
+	for _, p := range problems {
+		if p.Kind != BrokenLink || p.Target != nil {
+			continue
+		}
+		key := strings.ToLower(p.Link.Target)
+		i, ok := at[key]
+		if !ok {
+			i = len(out)
+			at[key] = i
+			out = append(out, DanglingTarget{Title: p.Link.Target})
+		}
+		if !containsNote(out[i].From, p.Note) {
+			out[i].From = append(out[i].From, p.Note)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return strings.ToLower(out[i].Title) < strings.ToLower(out[j].Title) })
+	return out
+}
+
+// CreateStub makes an empty note titled title, listing the notes that
+// link to it, so the links in from stop dangling.
+func (s *Store) CreateStub(title string, from []*Note) (*Note, error) {
+	n, err := s.Create(title, nil)
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	b.WriteString("Linked from:\n\n")
+	for _, f := range from {
+		b.WriteString("- [[" + f.Title + "]]\n")
+	}
+	n.Body = b.String()
+	if err := s.Save(n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// blockIDRe matches a ^block-id at the end of a line.
+var blockIDRe = regexp.MustCompile(`\s+\^[\w-]+$`)
+
+// hasHeading reports whether body has a markdown heading with text h.
+func hasHeading(body, h string) bool {
+	for _, line := range strings.Split(body, "\n") {
+		if !strings.HasPrefix(line, "#") {
+			continue
+		}
+		text := strings.TrimSpace(strings.TrimLeft(line, "#"))
+		text = blockIDRe.ReplaceAllString(text, "")
+		if strings.EqualFold(text, strings.TrimSpace(h)) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasBlock reports whether a line in body ends with the block ID ^id.
+func hasBlock(body, id string) bool {
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasSuffix(strings.TrimSpace(line), "^"+id) {
+			return true
+		}
+	}
+	return false
+}
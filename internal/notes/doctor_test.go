@@ -0,0 +1,66 @@
+package notes
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheck(t *testing.T) {
+	mk := func(id, raw string) *Note { return NoteFromRaw(id, "/vault/"+id+".md", raw, time.Time{}) }
+	all := []*Note{
+		mk("hub", "---\ntitle: Hub\n---\n\n# Intro ^top\n\n[[Spoke]] [[Spoke#Missing]] [[Gone]] [[gone|again]] [[#Intro]] [[Hub^nope]]"),
+		mk("spoke", "---\ntitle: Spoke\naliases: [Hub]\n---\n\nback to [[Hub^top]]"),
+		mk("lonely", "---\ntitle: Lonely\ncreated: yesterday\n---\n\nno links"),
+		mk("lonely-2", "---\ntitle: lonely\n---\n\nstill no links"),
+		mk("broken", "---\ntitle: Broken: yes\n---\n\n[[Spoke]]"),
+	}
+
+	got := map[ProblemKind][]string{}
+	for _, p := range Check(all) {
+		got[p.Kind] = append(got[p.Kind], p.Note.ID+": "+p.Detail)
+	}
+	want := map[ProblemKind][]string{
+		BrokenLink: {
+			"hub: no heading Missing in Spoke",
+			"hub: no note called Gone",
+			"hub: no note called gone",
+			"hub: no block ^nope in Hub",
+		},
+		Orphan:         {"lonely: no links in or out", "lonely-2: no links in or out"},
+		DuplicateTitle: {`lonely: 2 notes titled "Lonely"`},
+		AliasClash:     {`hub: alias "Hub" is claimed by 2 notes`},
+		BadTimestamp:   {`lonely: created: "yesterday" is not YYYY-MM-DDTHH:MM:SSZ`},
+	}
+	for kind, w := range want {
+		if len(got[kind]) != len(w) {
+			t.Errorf("%s: got %q, want %q", kind, got[kind], w)
+			continue
+		}
+		for i := range w {
+			if got[kind][i] != w[i] {
+				t.Errorf("%s[%d]: got %q, want %q", kind, i, got[kind][i], w[i])
+			}
+		}
+	}
+	if len(got[BadFrontmatter]) != 1 {
+		t.Errorf("bad frontmatter: got %q", got[BadFrontmatter])
+	}
+
+	dangling := Dangling(Check(all))
+	if len(dangling) != 1 || dangling[0].Title != "Gone" || len(dangling[0].From) != 1 {
+		t.Errorf("Dangling: %+v", dangling)
+	}
+}
+
+func TestCreateStub(t *testing.T) {
+	s := NewStore(t.TempDir())
+	from, _ := s.Create("Source", nil)
+	stub, err := s.CreateStub("Missing Piece", []*Note{from})
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, _ := s.Load(stub.ID)
+	if loaded.Title != "Missing Piece" || len(loaded.Links) != 1 || loaded.Links[0].Target != "Source" {
+		t.Errorf("stub: %q %+v", loaded.Title, loaded.Links)
+	}
+}
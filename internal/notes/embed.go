@@ -0,0 +1,161 @@
+package notes
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MaxEmbedDepth is how deeply embeds may nest before ExpandEmbeds stops
+// expanding them.
+const MaxEmbedDepth = 4
+
+// embedRe matches an embed, ![[Target]] or ![[Target#Section]].
+var embedRe = regexp.MustCompile(`!\[\[([^\]]+)\]\]`)
+
+// Embed is one ![[…]] being expanded.
+type Embed struct {
+	Link  Link
+	Note  *Note  // the embedded note; nil if it doesn't exist
+	Depth int    // 1 for embeds in the note itself, 2 for embeds in those…
+	Err   string // why the embed wasn't expanded, or ""
+}
+
+// EmbedFunc renders an expanded embed. content is the embedded text with
+// its own embeds already expanded; it is empty when e.Err is set.
+type EmbedFunc func(e Embed, content string) string
+
+// ExpandEmbeds replaces the embeds in n's body with the notes or sections
+// they point at, resolved through r, and returns the new body. Each embed
+// is passed to render, which decides how it looks. Embeds inside code are
+// left alone. An embed of a note or section that is already being
+// expanded, or one nested deeper than MaxEmbedDepth, isn't expanded;
+// render gets it with Err set.
+func ExpandEmbeds(n *Note, r *Resolver, render EmbedFunc) string {
+	return expandEmbeds(n, n.Body, r, render, []string{embedKey(n, Link{})}, 1)
+}
+
+func expandEmbeds(n *Note, body string, r *Resolver, render EmbedFunc, stack []string, depth int) string {
+	code := codeSpanRe.FindAllStringIndex(body, -1)
+	var b strings.Builder
+	last := 0
+	for _, m := range embedRe.FindAllStringSubmatchIndex(body, -1) {
+		if inSpans(code, m[0]) {
+			continue
+		}
+		b.WriteString(body[last:m[0]])
+		last = m[1]
+
+		e := Embed{Link: ParseLink(body[m[2]:m[3]]), Depth: depth}
+		e.Note = n
+		if e.Link.Target != "" {
+			e.Note = r.Resolve(e.Link.Target)
+		}
+		key := embedKey(e.Note, e.Link)
+		var content string
+		switch {
+		case e.Note == nil:
+			e.Err = "no note named " + e.Link.Target
+		case depth > MaxEmbedDepth:
+			e.Err = "embeds nested too deeply"
+		case containsString(stack, key):
+			e.Err = "embeds itself"
+		default:
+			section, ok := Section(e.Note.Body, e.Link)
+			if !ok {
+				e.Err = "no such section in " + e.Note.Title
+				break
+			}
+			content = expandEmbeds(e.Note, section, r, render, append(stack, key), depth+1)
+		}
+		b.WriteString(render(e, content))
+	}
+	b.WriteString(body[last:])
+	return b.String()
+}
+
+// Flatten returns n's body with its embeds replaced by the text they
+// point at. Embeds that can't be expanded are left as written.
+func Flatten(n *Note, r *Resolver) string {
+	return ExpandEmbeds(n, r, func(e Embed, content string) string {
+		if e.Err != "" {
+			return "![[" + e.Link.String() + "]]"
+		}
+		return content
+	})
+}
+
+// embedKey identifies what an embed shows, for cycle detection.
+func embedKey(n *Note, l Link) string {
+	if n == nil {
+		return ""
+	}
+	return n.ID + "#" + strings.ToLower(l.Heading) + "^" + l.Block
+}
+
+func inSpans(spans [][]int, i int) bool {
+	for _, s := range spans {
+		if s[0] <= i && i < s[1] {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(ss []string, s string) bool {
+	for _, t := range ss {
+		if t == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Section returns the part of body that l points at. For a heading that is
+// the heading line and everything up to the next heading of the same or a
+// higher level; for a block ID, the list item or paragraph ending in it,
+// without the ID. A link with neither gets the whole body. ok is false if
+// the heading or block isn't there.
+func Section(body string, l Link) (section string, ok bool) {
+	lines := strings.Split(body, "\n")
+	switch {
+	case l.Block != "":
+		for i, line := range lines {
+			if !strings.HasSuffix(strings.TrimSpace(line), "^"+l.Block) {
+				continue
+			}
+			start := i
+			if !isListItem(line) {
+				for start > 0 && strings.TrimSpace(lines[start-1]) != "" && !strings.HasPrefix(lines[start-1], "#") {
+					start--
+				}
+			}
+			lines[i] = blockIDRe.ReplaceAllString(strings.TrimRight(line, " \t"), "")
+			return strings.Join(lines[start:i+1], "\n"), true
+		}
+		return "", false
+
+	case l.Heading != "":
+		levels := headingLevels(lines)
+		for i, line := range lines {
+			level := levels[i]
+			if level == 0 {
+				continue
+			}
+			text := strings.TrimSpace(strings.TrimLeft(line, "#"))
+			text = blockIDRe.ReplaceAllString(text, "")
+			if !strings.EqualFold(text, strings.TrimSpace(l.Heading)) {
+				continue
+			}
+			end := len(lines)
+			for j := i + 1; j < len(lines); j++ {
+				if lv := levels[j]; lv > 0 && lv <= level {
+					end = j
+					break
+				}
+			}
+			return strings.TrimRight(strings.Join(lines[i:end], "\n"), "\n"), true
+		}
+		return "", false
+	}
+	return body, true
+}
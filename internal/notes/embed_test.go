@@ -0,0 +1,75 @@
+package notes
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSection(t *testing.T) {
+	body := "# Doc\n\nintro\n\n## Setup\n\nstep one\n\n### Detail\n\nfine print\n\n## Usage\n\nrun it\n" +
+		"\nfirst line\nsecond line ^para\n\n- item a\n- item b ^item\n"
+	tests := []struct {
+		link Link
+		want string
+		ok   bool
+	}{
+		{Link{Heading: "setup"}, "## Setup\n\nstep one\n\n### Detail\n\nfine print", true},
+		{Link{Heading: "Detail"}, "### Detail\n\nfine print", true},
+		{Link{Block: "para"}, "first line\nsecond line", true},
+		{Link{Block: "item"}, "- item b", true},
+		{Link{Heading: "Missing"}, "", false},
+		{Link{Block: "missing"}, "", false},
+		{Link{}, body, true},
+	}
+	for _, tt := range tests {
+		got, ok := Section(body, tt.link)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Section(%+v) = %q, %v; want %q, %v", tt.link, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestExpandEmbeds(t *testing.T) {
+	host := &Note{ID: "host", Title: "Host", Body: "top\n![[Guest#Part]]\n![[Missing]]\n`![[Guest]]`\n![[Host]]"}
+	guest := &Note{ID: "guest", Title: "Guest", Body: "## Part\n\npart text ![[#Tail]]\n\n## Tail\n\ntail text"}
+	r := NewResolver([]*Note{host, guest})
+
+	render := func(e Embed, content string) string {
+		if e.Err != "" {
+			return fmt.Sprintf("<%d err: %s>", e.Depth, e.Err)
+		}
+		return fmt.Sprintf("<%d %s: %s>", e.Depth, e.Note.ID, content)
+	}
+	got := ExpandEmbeds(host, r, render)
+	want := "top\n" +
+		"<1 guest: ## Part\n\npart text <2 guest: ## Tail\n\ntail text>>\n" +
+		"<1 err: no note named Missing>\n" +
+		"`![[Guest]]`\n" +
+		"<1 err: embeds itself>"
+	if got != want {
+		t.Errorf("ExpandEmbeds:\n got %q\nwant %q", got, want)
+	}
+
+	if got := Flatten(host, r); !strings.Contains(got, "part text ## Tail") || !strings.Contains(got, "![[Missing]]") {
+		t.Errorf("Flatten = %q", got)
+	}
+}
+
+func TestExpandEmbeds_depthLimit(t *testing.T) {
+	// A chain of distinct notes, each embedding the next
+	var all []*Note
+	for i := 0; i <= MaxEmbedDepth+1; i++ {
+		all = append(all, &Note{ID: fmt.Sprint(i), Title: fmt.Sprint("N", i), Body: fmt.Sprintf("![[N%d]]", i+1)})
+	}
+	got := ExpandEmbeds(all[0], NewResolver(all), func(e Embed, content string) string {
+		if e.Err != "" {
+			return e.Err
+		}
+		return "(" + content + ")"
+	})
+	want := strings.Repeat("(", MaxEmbedDepth) + "embeds nested too deeply" + strings.Repeat(")", MaxEmbedDepth)
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
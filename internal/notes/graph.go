@@ -0,0 +1,313 @@
+package notes
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Graph is the vault's link graph, with an edge from a to b when note a
+// wiki-links to note b (by title or alias). Links to missing notes and
+// links from a note to itself are left out, and several links between the
+// same two notes make one edge.
+type Graph struct {
+	Notes []*Note
+	index map[*Note]int
+	out   [][]int // note index -> indexes it links to, sorted
+	in    [][]int // note index -> indexes linking to it, sorted
+}
+
+// BuildGraph resolves the links in all into a graph.
+func BuildGraph(all []*Note) *Graph {
+	g := &Graph{
+		Notes: all,
+		index: make(map[*Note]int, len(all)),
+		out:   make([][]int, len(all)),
+		in:    make([][]int, len(all)),
+	}
+	for i, n := range all {
+		g.index[n] = i
+	}
+	r := NewResolver(all)
+	for i, n := range all {
+		links := n.Links
+		if links == nil {
+			links = ExtractLinks(n.Body)
+		}
+		seen := map[int]bool{}
+		for _, l := range links {
+			if l.Target == "" {
+				continue
+			}
+			t := r.Resolve(l.Target)
+			if t == nil {
+				continue
+			}
+			j := g.index[t]
+			if j == i || seen[j] {
+				continue
+			}
+			seen[j] = true
+			g.out[i] = append(g.out[i], j)
+			g.in[j] = append(g.in[j], i)
+		}
+		sort.Ints(g.out[i])
+	}
+	return g
+}
+
+// Out returns the notes n links to.
+func (g *Graph) Out(n *Note) []*Note { return g.notes(g.out, n) }
+
+// In returns the notes linking to n.
+func (g *Graph) In(n *Note) []*Note { return g.notes(g.in, n) }
+
+// Degree returns how many notes link to n and how many n links to.
+func (g *Graph) Degree(n *Note) (in, out int) {
+	i, ok := g.index[n]
+	if !ok {
+		return 0, 0
+	}
+	return len(g.in[i]), len(g.out[i])
+}
+
+// Links reports whether a links to b.
+func (g *Graph) Links(a, b *Note) bool {
+	i, ok := g.index[a]
+	j, ok2 := g.index[b]
+	if !ok || !ok2 {
+		return false
+	}
+	k := sort.SearchInts(g.out[i], j)
+	return k < len(g.out[i]) && g.out[i][k] == j
+}
+
+// Neighbors returns the notes linked to or from n, each once.
+func (g *Graph) Neighbors(n *Note) []*Note {
+	i, ok := g.index[n]
+	if !ok {
+		return nil
+	}
+	var out []*Note
+	for _, j := range g.neighbors(i) {
+		out = append(out, g.Notes[j])
+	}
+	return out
+}
+
+// Components returns the groups of notes connected by links in either
+// direction, largest first. Notes with no links are components of one.
+func (g *Graph) Components() [][]*Note {
+	seen := make([]bool, len(g.Notes))
+	var out [][]*Note
+	for start := range g.Notes {
+		if seen[start] {
+			continue
+		}
+		var comp []*Note
+		seen[start] = true
+		queue := []int{start}
+		for len(queue) > 0 {
+			i := queue[0]
+			queue = queue[1:]
+			comp = append(comp, g.Notes[i])
+			for _, j := range g.neighbors(i) {
+				if !seen[j] {
+					seen[j] = true
+					queue = append(queue, j)
+				}
+			}
+		}
+		out = append(out, comp)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return len(out[i]) > len(out[j]) })
+	return out
+}
+
+// ShortestPath returns the shortest chain of notes from one note to
+// another, following links in either direction, or nil if they aren't
+// connected. The path includes both ends.
+func (g *Graph) ShortestPath(from, to *Note) []*Note {
+	s, ok := g.index[from]
+	t, ok2 := g.index[to]
+	if !ok || !ok2 {
+		return nil
+	}
+	prev := make([]int, len(g.Notes))
+	for i := range prev {
+		prev[i] = -1
+	}
+	prev[s] = s
+	queue := []int{s}
+	for len(queue) > 0 && prev[t] == -1 {
+		i := queue[0]
+		queue = queue[1:]
+		for _, j := range g.neighbors(i) {
+			if prev[j] == -1 {
+				prev[j] = i
+				queue = append(queue, j)
+			}
+		}
+	}
+	if prev[t] == -1 {
+		return nil
+	}
+	var path []*Note
+	for i := t; ; i = prev[i] {
+		path = append(path, g.Notes[i])
+		if i == s {
+			break
+		}
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// Neighborhood returns the notes within hops links of n, in either
+// direction, with their distance from n. n itself is at distance 0.
+func (g *Graph) Neighborhood(n *Note, hops int) map[*Note]int {
+	i, ok := g.index[n]
+	if !ok {
+		return nil
+	}
+	dist := map[int]int{i: 0}
+	queue := []int{i}
+	for len(queue) > 0 {
+		k := queue[0]
+		queue = queue[1:]
+		if dist[k] == hops {
+			continue
+		}
+		for _, j := range g.neighbors(k) {
+			if _, ok := dist[j]; !ok {
+				dist[j] = dist[k] + 1
+				queue = append(queue, j)
+			}
+		}
+	}
+	out := make(map[*Note]int, len(dist))
+	for k, d := range dist {
+		out[g.Notes[k]] = d
+	}
+	return out
+}
+
+func (g *Graph) notes(adj [][]int, n *Note) []*Note {
+	i, ok := g.index[n]
+	if !ok {
+		return nil
+	}
+	out := make([]*Note, len(adj[i]))
+	for k, j := range adj[i] {
+		out[k] = g.Notes[j]
+	}
+	return out
+}
+
+// neighbors returns the indexes linked to or from i, sorted, each once.
+func (g *Graph) neighbors(i int) []int {
+	out := append([]int(nil), g.out[i]...)
+	for _, j := range g.in[i] {
+		k := sort.SearchInts(g.out[i], j)
+		if k == len(g.out[i]) || g.out[i][k] != j {
+			out = append(out, j)
+		}
+	}
+	sort.Ints(out)
+	return out
+}
+
+// ── Export ────────────────────────────────────────────────────────────────────
+
+// WriteDOT writes the graph in Graphviz DOT format.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph grove {\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, n := range g.Notes {
+		fmt.Fprintf(&b, "  %s [label=%s];\n", dotQuote(n.ID), dotQuote(n.Title))
+	}
+	for i, n := range g.Notes {
+		for _, j := range g.out[i] {
+			fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(n.ID), dotQuote(g.Notes[j].ID))
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart.
+func (g *Graph) WriteMermaid(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	for i, n := range g.Notes {
+		// Mermaid IDs must be simple words; the title goes in the label.
+		fmt.Fprintf(&b, "  n%d[\"%s\"]\n", i, strings.ReplaceAll(n.Title, `"`, "#quot;"))
+	}
+	for i := range g.Notes {
+		for _, j := range g.out[i] {
+			fmt.Fprintf(&b, "  n%d --> n%d\n", i, j)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type graphJSON struct {
+	Nodes []graphNodeJSON `json:"nodes"`
+	Edges []graphEdgeJSON `json:"edges"`
+}
+
+type graphNodeJSON struct {
+	ID        string   `json:"id"`
+	Title     string   `json:"title"`
+	Folder    string   `json:"folder,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	In        int      `json:"in"`
+	Out       int      `json:"out"`
+	Component int      `json:"component"`
+}
+
+type graphEdgeJSON struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// WriteJSON writes the graph as JSON: nodes with their degree and the
+// index of their connected component (0 is the largest), and edges by
+// note ID.
+func (g *Graph) WriteJSON(w io.Writer) error {
+	comp := map[*Note]int{}
+	for c, ns := range g.Components() {
+		for _, n := range ns {
+			comp[n] = c
+		}
+	}
+	out := graphJSON{Nodes: []graphNodeJSON{}, Edges: []graphEdgeJSON{}}
+	for i, n := range g.Notes {
+		out.Nodes = append(out.Nodes, graphNodeJSON{
+			ID:        n.ID,
+			Title:     n.Title,
+			Folder:    n.Folder,
+			Tags:      n.Tags,
+			In:        len(g.in[i]),
+			Out:       len(g.out[i]),
+			Component: comp[n],
+		})
+		for _, j := range g.out[i] {
+			out.Edges = append(out.Edges, graphEdgeJSON{From: n.ID, To: g.Notes[j].ID})
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
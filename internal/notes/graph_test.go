@@ -0,0 +1,97 @@
+package notes
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func graphNotes() (a, b, c, d, e *Note, all []*Note) {
+	a = &Note{ID: "a", Title: "Alpha", Body: "See [[Beta]] and [[Beta#Intro]] and [[Alpha]]."}
+	b = &Note{ID: "b", Title: "Beta", Aliases: []string{"B"}, Body: "On to [[Gamma]]. [[Missing]]"}
+	c = &Note{ID: "c", Title: "Gamma", Body: "Back to [[b]]."}
+	d = &Note{ID: "d", Title: "Delta", Body: "Points at [[Gamma|g]]."}
+	e = &Note{ID: "e", Title: "Epsilon", Body: "Alone."}
+	return a, b, c, d, e, []*Note{a, b, c, d, e}
+}
+
+func TestGraph(t *testing.T) {
+	a, b, c, d, e, all := graphNotes()
+	g := BuildGraph(all)
+
+	if in, out := g.Degree(a); in != 0 || out != 1 {
+		t.Errorf("Degree(a) = %d, %d; want 0, 1 (self and repeat links dropped)", in, out)
+	}
+	if in, out := g.Degree(c); in != 2 || out != 1 {
+		t.Errorf("Degree(c) = %d, %d; want 2, 1", in, out)
+	}
+	if !g.Links(c, b) || g.Links(b, a) {
+		t.Error("Links: alias edge c->b missing or unexpected b->a")
+	}
+	if got := g.Neighbors(b); len(got) != 2 || got[0] != a || got[1] != c {
+		t.Errorf("Neighbors(b) = %v", got)
+	}
+
+	comps := g.Components()
+	if len(comps) != 2 || len(comps[0]) != 4 || len(comps[1]) != 1 || comps[1][0] != e {
+		t.Errorf("Components = %v", comps)
+	}
+
+	if p := g.ShortestPath(a, d); len(p) != 4 || p[0] != a || p[1] != b || p[2] != c || p[3] != d {
+		t.Errorf("ShortestPath(a, d) = %v", p)
+	}
+	if p := g.ShortestPath(a, e); p != nil {
+		t.Errorf("ShortestPath(a, e) = %v, want nil", p)
+	}
+	if p := g.ShortestPath(a, a); len(p) != 1 {
+		t.Errorf("ShortestPath(a, a) = %v", p)
+	}
+
+	hood := g.Neighborhood(b, 1)
+	if len(hood) != 3 || hood[b] != 0 || hood[a] != 1 || hood[c] != 1 {
+		t.Errorf("Neighborhood(b, 1) = %v", hood)
+	}
+	if hood := g.Neighborhood(a, 2); len(hood) != 3 || hood[c] != 2 {
+		t.Errorf("Neighborhood(a, 2) = %v", hood)
+	}
+}
+
+func TestGraphExport(t *testing.T) {
+	_, _, _, _, _, all := graphNotes()
+	g := BuildGraph(all)
+
+	var buf bytes.Buffer
+	if err := g.WriteDOT(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if dot := buf.String(); !strings.Contains(dot, `"a" [label="Alpha"];`) || !strings.Contains(dot, `"c" -> "b";`) {
+		t.Errorf("DOT:\n%s", dot)
+	}
+
+	buf.Reset()
+	if err := g.WriteMermaid(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if m := buf.String(); !strings.HasPrefix(m, "graph LR\n") || !strings.Contains(m, `n0["Alpha"]`) || !strings.Contains(m, "n3 --> n2") {
+		t.Errorf("Mermaid:\n%s", m)
+	}
+
+	buf.Reset()
+	if err := g.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var got graphJSON
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Nodes) != 5 || len(got.Edges) != 4 {
+		t.Fatalf("JSON: %d nodes, %d edges", len(got.Nodes), len(got.Edges))
+	}
+	if n := got.Nodes[2]; n.ID != "c" || n.In != 2 || n.Out != 1 || n.Component != 0 {
+		t.Errorf("node c = %+v", n)
+	}
+	if got.Nodes[4].Component != 1 {
+		t.Errorf("node e component = %d", got.Nodes[4].Component)
+	}
+}
@@ -0,0 +1,159 @@
+package notes
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// indexVersion is bumped whenever indexEntry changes shape, so stale caches
+// from older grove versions are thrown away instead of misread.
+const indexVersion = 4
+
+// index caches parsed notes on disk so LoadAll only has to re-read files
+// whose mtime or size changed since the last run.
+type index struct {
+	path    string
+	loaded  bool
+	dirty   bool
+	entries map[string]indexEntry // keyed by note ID
+}
+
+type indexEntry struct {
+	ModTime int64 // UnixNano
+	Size    int64
+	Hash    string
+
+	Title   string
+	Tags    []string
+	Created time.Time
+	Updated time.Time
+	Body    string
+	Raw     string
+	Links   []Link
+	Words   int
+	Front   string // frontmatter as YAML; gob can't encode yaml.Node
+}
+
+type indexFile struct {
+	Version int
+	Dir     string
+	Entries map[string]indexEntry
+}
+
+// EnableIndex makes LoadAll keep a persistent index of parsed notes under
+// cacheDir. Each vault gets its own index file, named after its path.
+func (s *Store) EnableIndex(cacheDir string) {
+	sum := sha256.Sum256([]byte(s.dir))
+	name := "index-" + hex.EncodeToString(sum[:8]) + ".gob"
+	s.idx = &index{path: filepath.Join(cacheDir, name)}
+}
+
+// load reads the index from disk once. A missing, corrupt or outdated index
+// just starts out empty.
+func (ix *index) load(dir string) {
+	if ix.loaded {
+		return
+	}
+	ix.loaded = true
+	ix.entries = map[string]indexEntry{}
+
+	data, err := os.ReadFile(ix.path)
+	if err != nil {
+		return
+	}
+	var f indexFile
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&f); err != nil {
+		return
+	}
+	if f.Version != indexVersion || f.Dir != dir || f.Entries == nil {
+		return
+	}
+	ix.entries = f.Entries
+}
+
+// lookup returns the cached note for id if the file is unchanged.
+func (ix *index) lookup(id, path string, info os.FileInfo) (*Note, bool) {
+	e, ok := ix.entries[id]
+	if !ok || e.ModTime != info.ModTime().UnixNano() || e.Size != info.Size() {
+		return nil, false
+	}
+	front, err := parseFields(e.Front)
+	if err != nil {
+		return nil, false
+	}
+	n := &Note{
+		ID:       id,
+		Title:    e.Title,
+		Tags:     append([]string(nil), e.Tags...),
+		Folder:   ParentFolder(id),
+		Created:  e.Created,
+		Updated:  e.Updated,
+		Body:     e.Body,
+		Raw:      e.Raw,
+		Filename: path,
+		Links:    append([]Link(nil), e.Links...),
+		Words:    e.Words,
+		diskHash: e.Hash,
+	}
+	n.setFront(front)
+	return n, true
+}
+
+func (ix *index) put(n *Note, info os.FileInfo) {
+	if n.frontErr != nil {
+		return // the cache would lose what Save needs to refuse it
+	}
+	front, err := marshalFields(n.front)
+	if err != nil {
+		return // not cacheable; read the file next time
+	}
+	ix.entries[n.ID] = indexEntry{
+		ModTime: info.ModTime().UnixNano(),
+		Size:    info.Size(),
+		Hash:    n.diskHash,
+		Title:   n.Title,
+		Tags:    n.Tags,
+		Created: n.Created,
+		Updated: n.Updated,
+		Body:    n.Body,
+		Raw:     n.Raw,
+		Links:   n.Links,
+		Words:   n.Words,
+		Front:   front,
+	}
+	ix.dirty = true
+}
+
+// prune drops entries for notes that no longer exist.
+func (ix *index) prune(seen map[string]bool) {
+	for id := range ix.entries {
+		if !seen[id] {
+			delete(ix.entries, id)
+			ix.dirty = true
+		}
+	}
+}
+
+func (ix *index) save(dir string) error {
+	if !ix.dirty {
+		return nil
+	}
+	var buf bytes.Buffer
+	f := indexFile{Version: indexVersion, Dir: dir, Entries: ix.entries}
+	if err := gob.NewEncoder(&buf).Encode(&f); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ix.path), 0755); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(ix.path, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	ix.dirty = false
+	return nil
+}
@@ -0,0 +1,200 @@
+package notes
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIndex_servesUnchangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	cache := t.TempDir()
+	path := filepath.Join(dir, "cached.md")
+	_ = os.WriteFile(path, []byte("---\ntitle: Before\n---\n\nsee [[Other]]\n"), 0644)
+	mtime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	_ = os.Chtimes(path, mtime, mtime)
+
+	s := NewStore(dir)
+	s.EnableIndex(cache)
+	all, err := s.LoadAll()
+	if err != nil || len(all) != 1 {
+		t.Fatalf("LoadAll: %v, %d notes", err, len(all))
+	}
+	if len(all[0].Links) != 1 || all[0].Links[0].Target != "Other" || all[0].Words != 2 {
+		t.Errorf("derived fields: links %v, words %d", all[0].Links, all[0].Words)
+	}
+
+	// Same size and mtime: a fresh store (a new CLI run) must use the cache.
+	_ = os.WriteFile(path, []byte("---\ntitle: Afterr\n---\n\nsee [[Other]]\n"), 0644)
+	_ = os.Chtimes(path, mtime, mtime)
+	s2 := NewStore(dir)
+	s2.EnableIndex(cache)
+	all, _ = s2.LoadAll()
+	if all[0].Title != "Before" {
+		t.Errorf("expected cached title, got %q", all[0].Title)
+	}
+
+	// Touching the file invalidates the entry.
+	later := mtime.Add(time.Hour)
+	_ = os.Chtimes(path, later, later)
+	all, _ = s2.LoadAll()
+	if all[0].Title != "Afterr" {
+		t.Errorf("expected re-read title, got %q", all[0].Title)
+	}
+}
+
+func TestIndex_keepsExtraFrontmatter(t *testing.T) {
+	dir := t.TempDir()
+	cache := t.TempDir()
+	raw := "---\nstatus: draft\ntitle: \"Extra: fields\"\n---\n\nbody\n"
+	_ = os.WriteFile(filepath.Join(dir, "extra.md"), []byte(raw), 0644)
+
+	s := NewStore(dir)
+	s.EnableIndex(cache)
+	if _, err := s.LoadAll(); err != nil {
+		t.Fatal(err)
+	}
+	s2 := NewStore(dir)
+	s2.EnableIndex(cache)
+	all, _ := s2.LoadAll()
+	n := all[0]
+	if n.Extra.Scalar("status") != "draft" {
+		t.Fatalf("cached note lost extra fields: %+v", n.Extra)
+	}
+	if err := s2.Save(n); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(n.Raw, "---\nstatus: draft\ntitle: \"Extra: fields\"\n") {
+		t.Errorf("save from cache changed the frontmatter:\n%s", n.Raw)
+	}
+}
+
+func TestIndex_dropsDeletedAndSurvivesCorruption(t *testing.T) {
+	dir := t.TempDir()
+	cache := t.TempDir()
+	s := NewStore(dir)
+	s.EnableIndex(cache)
+
+	a, _ := s.Create("Keep", nil)
+	b, _ := s.Create("Drop", nil)
+	if all, _ := s.LoadAll(); len(all) != 2 {
+		t.Fatalf("expected 2 notes, got %d", len(all))
+	}
+	_ = s.Delete(b.ID)
+	all, _ := s.LoadAll()
+	if len(all) != 1 || all[0].ID != a.ID {
+		t.Errorf("deleted note still listed: %v", all)
+	}
+
+	_ = os.WriteFile(s.idx.path, []byte("not a gob"), 0644)
+	s2 := NewStore(dir)
+	s2.EnableIndex(cache)
+	all, err := s2.LoadAll()
+	if err != nil || len(all) != 1 {
+		t.Errorf("corrupt index: %v, %d notes", err, len(all))
+	}
+}
+
+func TestIndex_conflictDetectionStillWorks(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir)
+	s.EnableIndex(t.TempDir())
+	_, _ = s.Create("Shared", nil)
+
+	all, _ := s.LoadAll() // builds the index
+	all, _ = s.LoadAll()  // served from it
+	n := all[0]
+	_ = os.WriteFile(n.Filename, []byte("---\ntitle: Shared\n---\n\nexternal edit\n"), 0644)
+	n.Body = "mine"
+	if err := s.Save(n); err == nil {
+		t.Error("expected a conflict for a note served from the index")
+	}
+}
+
+func writeBenchVault(b *testing.B, n int) string {
+	b.Helper()
+	dir := b.TempDir()
+	body := strings.Repeat("Some words about [[Another Note]] and #ideas.\n\n", 40)
+	for i := 0; i < n; i++ {
+		content := fmt.Sprintf("---\ntitle: Note %d\ntags: [bench, n%d]\ncreated: 2024-01-01T00:00:00Z\nupdated: 2024-06-01T00:00:00Z\n---\n\n%s", i, i%10, body)
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("note-%d.md", i)), []byte(content), 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// BenchmarkLoadAll_cold measures a run with no index on disk.
+func BenchmarkLoadAll_cold(b *testing.B) {
+	dir := writeBenchVault(b, 2000)
+	cache := b.TempDir()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		_ = os.RemoveAll(cache)
+		s := NewStore(dir)
+		s.EnableIndex(cache)
+		b.StartTimer()
+		if _, err := s.LoadAll(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkLoadAll_warm measures a fresh process with an up-to-date index.
+func BenchmarkLoadAll_warm(b *testing.B) {
+	dir := writeBenchVault(b, 2000)
+	cache := b.TempDir()
+	s := NewStore(dir)
+	s.EnableIndex(cache)
+	if _, err := s.LoadAll(); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := NewStore(dir)
+		s.EnableIndex(cache)
+		if _, err := s.LoadAll(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkLoadAll_noIndex is the baseline without any caching.
+func BenchmarkLoadAll_noIndex(b *testing.B) {
+	dir := writeBenchVault(b, 2000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewStore(dir).LoadAll(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestIndex_keepsUnreadableFrontmatterUnsaveable(t *testing.T) {
+	dir := t.TempDir()
+	cache := t.TempDir()
+	raw := "---\ntitle: Plan\nproject:\n  name: Q3: plan\n---\n\nbody\n"
+	path := filepath.Join(dir, "plan.md")
+	_ = os.WriteFile(path, []byte(raw), 0644)
+
+	for run := 1; run <= 2; run++ {
+		s := NewStore(dir)
+		s.EnableIndex(cache)
+		all, err := s.LoadAll()
+		if err != nil || len(all) != 1 {
+			t.Fatalf("run %d: LoadAll: %v, %d notes", run, err, len(all))
+		}
+		var fe *FrontmatterError
+		if err := s.Save(all[0]); !errors.As(err, &fe) {
+			t.Errorf("run %d: Save: got %v, want a *FrontmatterError", run, err)
+		}
+	}
+	if data, _ := os.ReadFile(path); string(data) != raw {
+		t.Errorf("file was rewritten:\n%s", data)
+	}
+}
@@ -0,0 +1,127 @@
+package notes
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Mention is a plain-text occurrence of a note's title or alias in another
+// note's body, where a wiki-link could be.
+type Mention struct {
+	Note    *Note  // the note containing the text
+	Text    string // the text as written
+	Start   int    // byte offset of Text in Note.Body
+	Line    int    // 1-based line in Note.Body
+	Context string // the whole line, trimmed
+}
+
+// codeSpanRe matches fenced code blocks and inline code, where mentions
+// are left alone.
+var codeSpanRe = regexp.MustCompile("(?s)```.*?(```|$)|`[^`\n]+`")
+
+// UnlinkedMentions finds whole-word, case-insensitive occurrences of
+// target's title and aliases in the other notes of all, skipping text that
+// is already inside a wiki-link or code. Where names overlap, the longest
+// one wins.
+func UnlinkedMentions(target *Note, all []*Note) []Mention {
+	names := Names(target)
+	sort.SliceStable(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+
+	var out []Mention
+	for _, n := range all {
+		if n == target || n.ID == target.ID {
+			continue
+		}
+		out = append(out, findMentions(n, names)...)
+	}
+	return out
+}
+
+func findMentions(n *Note, names []string) []Mention {
+	body := n.Body
+	var skip [][]int
+	skip = append(skip, wikiLinkRe.FindAllStringIndex(body, -1)...)
+	skip = append(skip, codeSpanRe.FindAllStringIndex(body, -1)...)
+	sort.Slice(skip, func(i, j int) bool { return skip[i][0] < skip[j][0] })
+
+	var out []Mention
+	s := 0 // next skip range that may cover i
+	for i := 0; i < len(body); {
+		for s < len(skip) && skip[s][1] <= i {
+			s++
+		}
+		if s < len(skip) && skip[s][0] <= i {
+			i = skip[s][1]
+			continue
+		}
+		if end := matchName(body, i, names); end > 0 && (s == len(skip) || end <= skip[s][0]) {
+			out = append(out, newMention(n, i, end))
+			i = end
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(body[i:])
+		i += size
+	}
+	return out
+}
+
+// matchName returns the end of the longest name in names that occurs as a
+// whole word at body[i:], or 0.
+func matchName(body string, i int, names []string) int {
+	if i > 0 {
+		if r, _ := utf8.DecodeLastRuneInString(body[:i]); isWordRune(r) {
+			return 0
+		}
+	}
+	for _, name := range names {
+		end := i + len(name)
+		if name == "" || end > len(body) || !strings.EqualFold(body[i:end], name) {
+			continue
+		}
+		if r, _ := utf8.DecodeRuneInString(body[end:]); end < len(body) && isWordRune(r) {
+			continue
+		}
+		return end
+	}
+	return 0
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func newMention(n *Note, start, end int) Mention {
+	lineStart := strings.LastIndexByte(n.Body[:start], '\n') + 1
+	lineEnd := strings.IndexByte(n.Body[end:], '\n')
+	if lineEnd == -1 {
+		lineEnd = len(n.Body)
+	} else {
+		lineEnd += end
+	}
+	return Mention{
+		Note:    n,
+		Text:    n.Body[start:end],
+		Start:   start,
+		Line:    strings.Count(n.Body[:start], "\n") + 1,
+		Context: strings.TrimSpace(n.Body[lineStart:lineEnd]),
+	}
+}
+
+// LinkMention turns m into a wiki-link to target in body: [[Title]] when
+// the text is the title as written, otherwise [[Title|text]] so the note
+// reads the same. It reports false if body no longer has m's text at m's
+// offset, e.g. because the note was edited since m was found.
+func LinkMention(body string, m Mention, target *Note) (string, bool) {
+	end := m.Start + len(m.Text)
+	if end > len(body) || body[m.Start:end] != m.Text {
+		return body, false
+	}
+	link := Link{Target: target.Title}
+	if m.Text != target.Title {
+		link.Label = m.Text
+	}
+	return body[:m.Start] + "[[" + link.String() + "]]" + body[end:], true
+}
@@ -0,0 +1,47 @@
+package notes
+
+import "testing"
+
+func TestUnlinkedMentions(t *testing.T) {
+	atlas := &Note{ID: "atlas", Title: "Project Atlas", Aliases: []string{"Atlas"}}
+	standup := &Note{ID: "standup", Title: "Standup", Body: "Talked about project atlas today.\n" +
+		"Already linked: [[Project Atlas]] and [[Atlas|the project]].\n" +
+		"Atlases and Atlas_v2 are not mentions; `Atlas` in code isn't either.\n" +
+		"```\nAtlas\n```\n" +
+		"Atlas again, and Atlas."}
+	r := &Note{ID: "r", Title: "Elsewhere", Body: "nothing here"}
+	all := []*Note{atlas, standup, r}
+
+	got := UnlinkedMentions(atlas, all)
+	want := []struct {
+		text string
+		line int
+	}{{"project atlas", 1}, {"Atlas", 7}, {"Atlas", 7}}
+	if len(got) != len(want) {
+		t.Fatalf("got %d mentions, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Text != w.text || got[i].Line != w.line || got[i].Note != standup {
+			t.Errorf("mention %d = %+v, want %q on line %d", i, got[i], w.text, w.line)
+		}
+	}
+	if got[1].Context != "Atlas again, and Atlas." {
+		t.Errorf("context = %q", got[1].Context)
+	}
+
+	body, ok := LinkMention(standup.Body, got[0], atlas)
+	if !ok {
+		t.Fatal("LinkMention failed")
+	}
+	if want := "Talked about [[Project Atlas|project atlas]] today.\n"; body[:len(want)] != want {
+		t.Errorf("linked body starts %q", body[:len(want)])
+	}
+
+	exact := Mention{Text: "Project Atlas", Start: 4}
+	if got, _ := LinkMention("See Project Atlas.", exact, atlas); got != "See [[Project Atlas]]." {
+		t.Errorf("exact title: %q", got)
+	}
+	if _, ok := LinkMention("changed", got[2], atlas); ok {
+		t.Error("LinkMention succeeded on a changed body")
+	}
+}
@@ -1,55 +1,189 @@
 package notes
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // wikiLinkRe matches [[link target]] syntax.
 var wikiLinkRe = regexp.MustCompile(`\[\[([^\]]+)\]\]`)
 
-// ExtractLinks returns all wiki-link targets found in body.
-// Duplicate targets are included once each (deduped).
-func ExtractLinks(body string) []string {
+// Link is a parsed wiki-link: [[Target#Heading|Label]] or
+// [[Target^block|Label]]. Every part but Target is optional, and Target is
+// empty for links within the same note, like [[#Heading]].
+type Link struct {
+	Target  string // note title or alias
+	Heading string // section to jump to
+	Block   string // block ID, as marked with ^id at the end of a line
+	Label   string // text to show instead of the link
+}
+
+// ParseLink parses the text between the brackets of a wiki-link.
+func ParseLink(s string) Link {
+	var l Link
+	if i := strings.Index(s, "|"); i != -1 {
+		s, l.Label = s[:i], strings.TrimSpace(s[i+1:])
+	}
+	if i := strings.Index(s, "^"); i != -1 {
+		s, l.Block = s[:i], strings.TrimSpace(s[i+1:])
+	}
+	if i := strings.Index(s, "#"); i != -1 {
+		s, l.Heading = s[:i], strings.TrimSpace(s[i+1:])
+	}
+	l.Target = strings.TrimSpace(s)
+	return l
+}
+
+// String returns the link in wiki-link syntax, without the brackets.
+func (l Link) String() string {
+	s := l.Target
+	if l.Heading != "" {
+		s += "#" + l.Heading
+	}
+	if l.Block != "" {
+		if l.Heading == "" {
+			s += "#"
+		}
+		s += "^" + l.Block
+	}
+	if l.Label != "" {
+		s += "|" + l.Label
+	}
+	return s
+}
+
+// ExtractLinks returns all wiki-links found in body, each distinct link
+// once, in order of first appearance.
+func ExtractLinks(body string) []Link {
 	matches := wikiLinkRe.FindAllStringSubmatch(body, -1)
-	seen := map[string]bool{}
-	var out []string
+	seen := map[Link]bool{}
+	var out []Link
 	for _, m := range matches {
-		target := strings.TrimSpace(m[1])
-		if target != "" && !seen[target] {
-			seen[target] = true
-			out = append(out, target)
+		l := ParseLink(m[1])
+		if (l.Target != "" || l.Heading != "" || l.Block != "") && !seen[l] {
+			seen[l] = true
+			out = append(out, l)
 		}
 	}
 	return out
 }
 
-// Backlinks returns all notes in all that contain a [[targetTitle]] wiki-link.
-func Backlinks(targetTitle string, all []*Note) []*Note {
-	target := "[[" + targetTitle + "]]"
-	targetLower := strings.ToLower(target)
+// Backlinks returns the other notes in all that wiki-link to target, as r
+// resolves their links: the same notes the graph and doctor count.
+func Backlinks(target *Note, all []*Note, r *Resolver) []*Note {
 	var result []*Note
 	for _, n := range all {
-		if strings.Contains(strings.ToLower(n.Body), targetLower) {
-			result = append(result, n)
+		if n.ID == target.ID {
+			continue
+		}
+		links := n.Links
+		if links == nil {
+			links = ExtractLinks(n.Body)
+		}
+		for _, l := range links {
+			if l.Target == "" {
+				continue
+			}
+			if t := r.Resolve(l.Target); t != nil && t.ID == target.ID {
+				result = append(result, n)
+				break
+			}
 		}
 	}
 	return result
 }
 
 type Note struct {
-	ID       string    // filename without extension
+	ID       string // path relative to the notes dir, without extension
 	Title    string
 	Tags     []string
+	Folder   string   // slash-separated folder relative to the notes dir; "" at top level
+	Aliases  []string // other names wiki-links may use for this note
 	Created  time.Time
 	Updated  time.Time
 	Body     string // content after frontmatter
 	Raw      string // full file content
 	Filename string // full path
+
+	// Extra holds the frontmatter fields grove doesn't manage, such as
+	// status or aliases, so saving a note keeps them.
+	Extra Frontmatter
+
+	Links []Link // wiki-links in Body, as ExtractLinks returns them
+	Words int    // word count of Body
+
+	// front is the frontmatter as read from disk. BuildFrontmatter follows
+	// its key order and keeps the quoting and comments of grove's own keys.
+	front Frontmatter
+
+	// frontErr is set if the frontmatter couldn't be read in full, in
+	// which case saving would lose some of it.
+	frontErr *FrontmatterError
+
+	// diskHash is the hash of the file content this note was loaded from
+	// (or last saved as). Save uses it to detect external edits.
+	diskHash string
+}
+
+// Field is one top-level frontmatter key and its YAML value.
+type Field struct {
+	Key   string
+	Value *yaml.Node
+	key   *yaml.Node // the key as parsed, so its comments survive a save
+}
+
+// Frontmatter is a note's YAML header, keys in file order.
+type Frontmatter []Field
+
+// Get returns the value of key, or nil if it is not set.
+func (fm Frontmatter) Get(key string) *yaml.Node {
+	for _, f := range fm {
+		if f.Key == key {
+			return f.Value
+		}
+	}
+	return nil
 }
 
-// ParseFrontmatter extracts title/tags/dates from YAML-like frontmatter.
+// Scalar returns the value of key if it is a plain value, or "".
+func (fm Frontmatter) Scalar(key string) string {
+	if v := fm.Get(key); v != nil && v.Kind == yaml.ScalarNode {
+		return v.Value
+	}
+	return ""
+}
+
+// Set sets key to v, keeping its place (and comments) if it is already
+// set and adding it at the end if not.
+func (fm *Frontmatter) Set(key string, v *yaml.Node) {
+	for i, f := range *fm {
+		if f.Key == key {
+			keepStyle(v, f.Value)
+			(*fm)[i].Value = v
+			return
+		}
+	}
+	*fm = append(*fm, Field{Key: key, Value: v})
+}
+
+// groveKeys are the frontmatter keys grove manages itself, in the order it
+// writes them. Every other key, aliases included, is kept verbatim in
+// Note.Extra.
+var groveKeys = []string{"title", "tags", "created", "updated"}
+
+func isGroveKey(key string) bool {
+	return slices.Contains(groveKeys, key)
+}
+
+// ParseFrontmatter splits content into its YAML frontmatter and body.
 // Format:
 //
 //	---
@@ -58,8 +192,14 @@ type Note struct {
 //	created: 2024-01-01T00:00:00Z
 //	updated: 2024-01-01T00:00:00Z
 //	---
-func ParseFrontmatter(content string) (meta map[string]string, body string) {
-	meta = make(map[string]string)
+//
+// If the frontmatter is not valid YAML (older grove versions wrote titles
+// unquoted), err says why. fm then holds each field as YAML reads it on its
+// own, except that a one-line field YAML can't read is taken as a plain
+// string. If some other field can't be read, fm holds what a line-by-line
+// read recovers and err is a *FrontmatterError, since saving it would
+// lose data.
+func ParseFrontmatter(content string) (fm Frontmatter, body string, err error) {
 	// Normalize CRLF to LF
 	content = strings.ReplaceAll(content, "\r\n", "\n")
 	body = content
@@ -74,17 +214,214 @@ func ParseFrontmatter(content string) (meta map[string]string, body string) {
 	}
 	end += 3 // account for offset
 
-	fm := content[4:end] // skip leading ---\n
+	text := ""
+	if end > 4 {
+		text = content[4:end] // skip leading ---\n
+	}
 	body = strings.TrimLeft(content[end+4:], "\n")
 
-	for _, line := range strings.Split(fm, "\n") {
+	fm, err = parseFields(text)
+	if err != nil {
+		var ok bool
+		if fm, ok = repairFields(text); !ok {
+			fm = parseLines(text)
+			err = &FrontmatterError{Err: err}
+		}
+	}
+	return
+}
+
+// FrontmatterError is returned by ParseFrontmatter for frontmatter grove
+// can't read without losing some of it. Store.Save refuses to rewrite such
+// a note.
+type FrontmatterError struct {
+	Err error // the YAML error
+}
+
+func (e *FrontmatterError) Error() string {
+	return "frontmatter can't be read without losing data: " + e.Err.Error()
+}
+
+func (e *FrontmatterError) Unwrap() error { return e.Err }
+
+// parseFields parses a YAML mapping into fields.
+func parseFields(text string) (Frontmatter, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(text), &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("frontmatter is not a list of key: value fields")
+	}
+	fm := make(Frontmatter, 0, len(root.Content)/2)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		k, v := root.Content[i], root.Content[i+1]
+		fm = append(fm, Field{Key: k.Value, Value: v, key: k})
+	}
+	return fm, nil
+}
+
+// repairFields parses text one top-level field at a time, so one bad line
+// doesn't cost the rest: a field that isn't valid YAML is taken as a plain
+// string if it is written on one line. ok is false if some field still
+// can't be read.
+func repairFields(text string) (fm Frontmatter, ok bool) {
+	for _, chunk := range fieldChunks(text) {
+		fields, err := parseFields(chunk)
+		if foot := trailingComments(chunk); err == nil && len(fields) > 0 && foot != "" {
+			// Comments after the last field can be dropped with the
+			// document they end.
+			if out, _ := marshalFields(fields); !strings.Contains(out, foot) {
+				last := fields[len(fields)-1].Value
+				last.FootComment = strings.TrimLeft(last.FootComment+"\n"+foot, "\n")
+			}
+		}
+		if err == nil {
+			fm = append(fm, fields...)
+			continue
+		}
+		var line string
+		var head, foot []string
+		for _, l := range strings.Split(chunk, "\n") {
+			switch {
+			case !isCommentLine(l) && line == "":
+				line = l
+			case !isCommentLine(l):
+				return nil, false
+			case strings.TrimSpace(l) == "":
+			case line == "":
+				head = append(head, strings.TrimSpace(l))
+			default:
+				foot = append(foot, strings.TrimSpace(l))
+			}
+		}
+		key, val, found := strings.Cut(line, ":")
+		if !found {
+			return nil, false
+		}
+		key = strings.TrimSpace(key)
+		fm = append(fm, Field{
+			Key:   key,
+			Value: &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: strings.TrimSpace(val), FootComment: strings.Join(foot, "\n")},
+			key:   &yaml.Node{Kind: yaml.ScalarNode, Value: key, HeadComment: strings.Join(head, "\n")},
+		})
+	}
+	return fm, true
+}
+
+// fieldChunks splits a YAML mapping into its top-level fields, each with
+// the comments and blank lines just above it.
+func fieldChunks(text string) []string {
+	var chunks []string
+	var pending string
+	for _, line := range strings.SplitAfter(text, "\n") {
+		switch {
+		case line == "":
+		case isCommentLine(line):
+			pending += line
+		case strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "-"):
+			if len(chunks) == 0 {
+				chunks = append(chunks, "")
+			}
+			chunks[len(chunks)-1] += pending + line
+			pending = ""
+		default:
+			chunks = append(chunks, pending+line)
+			pending = ""
+		}
+	}
+	if pending != "" {
+		if len(chunks) == 0 {
+			chunks = append(chunks, "")
+		}
+		chunks[len(chunks)-1] += pending
+	}
+	return chunks
+}
+
+// trailingComments returns the comment lines that end chunk.
+func trailingComments(chunk string) string {
+	lines := strings.Split(chunk, "\n")
+	i := len(lines)
+	for i > 0 && isCommentLine(lines[i-1]) {
+		i--
+	}
+	var out []string
+	for _, l := range lines[i:] {
+		if c := strings.TrimSpace(l); c != "" {
+			out = append(out, c)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+func isCommentLine(line string) bool {
+	t := strings.TrimSpace(line)
+	return t == "" || strings.HasPrefix(t, "#")
+}
+
+// parseLines reads frontmatter the way grove did before it parsed YAML:
+// each "key: value" line becomes a plain value.
+func parseLines(text string) Frontmatter {
+	var fm Frontmatter
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "#") {
+			continue
+		}
 		if idx := strings.Index(line, ":"); idx != -1 {
 			key := strings.TrimSpace(line[:idx])
 			val := strings.TrimSpace(line[idx+1:])
-			meta[key] = val
+			fm = append(fm, Field{Key: key, Value: &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: val}})
 		}
 	}
-	return
+	return fm
+}
+
+// marshalFields renders fm as a YAML mapping.
+func marshalFields(fm Frontmatter) (string, error) {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, f := range fm {
+		key := f.key
+		if key == nil {
+			key = &yaml.Node{Kind: yaml.ScalarNode, Value: f.Key}
+		}
+		root.Content = append(root.Content, key, f.Value)
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// listFrom reads a list written as YAML or as a comma-separated string,
+// as tags and aliases may be.
+func listFrom(v *yaml.Node) []string {
+	if v == nil {
+		return nil
+	}
+	switch v.Kind {
+	case yaml.SequenceNode:
+		var tags []string
+		for _, item := range v.Content {
+			if t := strings.TrimSpace(item.Value); item.Kind == yaml.ScalarNode && t != "" {
+				tags = append(tags, t)
+			}
+		}
+		return tags
+	case yaml.ScalarNode:
+		return parseTags(v.Value)
+	}
+	return nil
 }
 
 func parseTags(raw string) []string {
@@ -104,48 +441,135 @@ func parseTags(raw string) []string {
 	return tags
 }
 
+// BuildFrontmatter renders the frontmatter for n: grove's own keys from
+// the note's fields, then everything in Extra, in the order the keys had
+// on disk.
 func BuildFrontmatter(n *Note) string {
-	tags := "[]"
-	if len(n.Tags) > 0 {
-		quoted := make([]string, len(n.Tags))
-		for i, t := range n.Tags {
-			quoted[i] = t
+	tags := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+	for _, t := range n.Tags {
+		tags.Content = append(tags.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: t})
+	}
+	own := map[string]*yaml.Node{
+		"title":   {Kind: yaml.ScalarNode, Tag: "!!str", Value: n.Title},
+		"tags":    tags,
+		"created": {Kind: yaml.ScalarNode, Value: n.Created.UTC().Format(time.RFC3339)},
+		"updated": {Kind: yaml.ScalarNode, Value: n.Updated.UTC().Format(time.RFC3339)},
+	}
+
+	var fm Frontmatter
+	for _, key := range fieldOrder(n) {
+		if v, ok := own[key]; ok {
+			f := Field{Key: key, Value: v}
+			for _, old := range n.front {
+				if old.Key == key {
+					f.key = old.key
+					keepStyle(v, old.Value)
+				}
+			}
+			fm = append(fm, f)
+		} else {
+			for _, f := range n.Extra {
+				if f.Key == key {
+					fm = append(fm, f)
+					break
+				}
+			}
+		}
+	}
+	text, err := marshalFields(fm)
+	if err != nil {
+		// Extra holds something YAML can't encode; never lose the note
+		// itself over it.
+		var mine Frontmatter
+		for _, key := range groveKeys {
+			mine = append(mine, Field{Key: key, Value: own[key]})
+		}
+		text, _ = marshalFields(mine)
+	}
+	return "---\n" + text + "---\n\n"
+}
+
+// keepStyle carries quoting, list style and comments over from the node a
+// value was read from.
+func keepStyle(v, old *yaml.Node) {
+	if old.Kind == v.Kind {
+		v.Style = old.Style
+	}
+	v.HeadComment, v.LineComment, v.FootComment = old.HeadComment, old.LineComment, old.FootComment
+}
+
+// fieldOrder returns the keys to write for n: the order they were read in,
+// with any missing grove keys inserted after the last one present (or
+// first) and new Extra keys at the end.
+func fieldOrder(n *Note) []string {
+	keys := make([]string, 0, len(n.front)+len(groveKeys))
+	for _, f := range n.front {
+		keys = append(keys, f.Key)
+	}
+	at := 0
+	for i, k := range keys {
+		if isGroveKey(k) {
+			at = i + 1
+		}
+	}
+	for _, k := range groveKeys {
+		if !slices.Contains(keys, k) {
+			keys = slices.Insert(keys, at, k)
+			at++
+		}
+	}
+	for _, f := range n.Extra {
+		if !slices.Contains(keys, f.Key) {
+			keys = append(keys, f.Key)
 		}
-		tags = "[" + strings.Join(quoted, ", ") + "]"
 	}
-	return "---\ntitle: " + n.Title +
-		"\ntags: " + tags +
-		"\ncreated: " + n.Created.UTC().Format(time.RFC3339) +
-		"\nupdated: " + n.Updated.UTC().Format(time.RFC3339) +
-		"\n---\n\n"
+	return keys
 }
 
 func NoteFromRaw(id, filename, raw string, modTime time.Time) *Note {
-	meta, body := ParseFrontmatter(raw)
+	fm, body, err := ParseFrontmatter(raw)
 
-	title := meta["title"]
+	title := fm.Scalar("title")
 	if title == "" {
-		title = id
+		title = path.Base(id)
 	}
 
 	created := modTime
-	if t, err := time.Parse(time.RFC3339, meta["created"]); err == nil {
+	if t, err := time.Parse(time.RFC3339, fm.Scalar("created")); err == nil {
 		created = t
 	}
 
 	updated := modTime
-	if t, err := time.Parse(time.RFC3339, meta["updated"]); err == nil {
+	if t, err := time.Parse(time.RFC3339, fm.Scalar("updated")); err == nil {
 		updated = t
 	}
 
-	return &Note{
+	n := &Note{
 		ID:       id,
 		Title:    title,
-		Tags:     parseTags(meta["tags"]),
+		Tags:     listFrom(fm.Get("tags")),
+		Folder:   ParentFolder(id),
 		Created:  created,
 		Updated:  updated,
 		Body:     body,
 		Raw:      raw,
 		Filename: filename,
+		Links:    ExtractLinks(body),
+		Words:    len(strings.Fields(body)),
+	}
+	n.setFront(fm)
+	errors.As(err, &n.frontErr)
+	return n
+}
+
+// setFront records fm as the frontmatter read from disk and fills Extra.
+func (n *Note) setFront(fm Frontmatter) {
+	n.front = fm
+	n.Aliases = listFrom(fm.Get("aliases"))
+	n.Extra = nil
+	for _, f := range fm {
+		if !isGroveKey(f.Key) {
+			n.Extra = append(n.Extra, f)
+		}
 	}
 }
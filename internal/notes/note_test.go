@@ -1,19 +1,29 @@
 package notes
 
 import (
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestParseFrontmatter_full(t *testing.T) {
 	content := "---\ntitle: Test Note\ntags: [work, ideas]\ncreated: 2024-01-01T00:00:00Z\nupdated: 2024-06-01T00:00:00Z\n---\n\nBody text here."
-	meta, body := ParseFrontmatter(content)
+	meta, body, err := ParseFrontmatter(content)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	if meta["title"] != "Test Note" {
-		t.Errorf("title: got %q", meta["title"])
+	if meta.Scalar("title") != "Test Note" {
+		t.Errorf("title: got %q", meta.Scalar("title"))
 	}
-	if meta["tags"] != "[work, ideas]" {
-		t.Errorf("tags: got %q", meta["tags"])
+	if tags := listFrom(meta.Get("tags")); len(tags) != 2 || tags[0] != "work" || tags[1] != "ideas" {
+		t.Errorf("tags: got %v", tags)
 	}
 	if body != "Body text here." {
 		t.Errorf("body: got %q", body)
@@ -22,7 +32,7 @@ func TestParseFrontmatter_full(t *testing.T) {
 
 func TestParseFrontmatter_noFrontmatter(t *testing.T) {
 	content := "# Just a plain note\n\nNo frontmatter here."
-	meta, body := ParseFrontmatter(content)
+	meta, body, _ := ParseFrontmatter(content)
 
 	if len(meta) != 0 {
 		t.Errorf("expected empty meta, got %v", meta)
@@ -34,16 +44,42 @@ func TestParseFrontmatter_noFrontmatter(t *testing.T) {
 
 func TestParseFrontmatter_emptyBody(t *testing.T) {
 	content := "---\ntitle: Empty\ntags: []\n---\n"
-	meta, body := ParseFrontmatter(content)
+	meta, body, _ := ParseFrontmatter(content)
 
-	if meta["title"] != "Empty" {
-		t.Errorf("title: got %q", meta["title"])
+	if meta.Scalar("title") != "Empty" {
+		t.Errorf("title: got %q", meta.Scalar("title"))
 	}
 	if body != "" {
 		t.Errorf("body should be empty, got %q", body)
 	}
 }
 
+func TestParseFrontmatter_invalidYAML(t *testing.T) {
+	// Older grove versions wrote titles unquoted
+	content := "---\ntitle: Q3 plan: draft\ntags: [planning]\n---\n\nbody"
+	meta, body, err := ParseFrontmatter(content)
+	if err == nil {
+		t.Error("expected a YAML error")
+	}
+	if meta.Scalar("title") != "Q3 plan: draft" {
+		t.Errorf("title: got %q", meta.Scalar("title"))
+	}
+	if body != "body" {
+		t.Errorf("body: got %q", body)
+	}
+}
+
+func TestNoteFromRaw_tagsAsBlockList(t *testing.T) {
+	raw := "---\ntitle: Books\ntags:\n  - reading\n  - to-read\nstatus: active\n---\n\nbody"
+	n := NoteFromRaw("books", "/path/books.md", raw, time.Now())
+	if len(n.Tags) != 2 || n.Tags[0] != "reading" || n.Tags[1] != "to-read" {
+		t.Errorf("Tags: got %v", n.Tags)
+	}
+	if len(n.Extra) != 1 || n.Extra.Scalar("status") != "active" {
+		t.Errorf("Extra: got %+v", n.Extra)
+	}
+}
+
 func TestParseTags(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -102,9 +138,9 @@ func TestNoteFromRaw_noFrontmatter(t *testing.T) {
 func TestParseFrontmatter_CRLF(t *testing.T) {
 	// Files created on Windows or synced via some tools may have CRLF
 	content := "---\r\ntitle: CRLF Note\r\ntags: [test]\r\n---\r\n\r\nBody here."
-	meta, body := ParseFrontmatter(content)
-	if meta["title"] != "CRLF Note" {
-		t.Errorf("title with CRLF: got %q", meta["title"])
+	meta, body, _ := ParseFrontmatter(content)
+	if meta.Scalar("title") != "CRLF Note" {
+		t.Errorf("title with CRLF: got %q", meta.Scalar("title"))
 	}
 	if body != "Body here." {
 		t.Errorf("body with CRLF: got %q", body)
@@ -162,14 +198,39 @@ func TestExtractLinks(t *testing.T) {
 				return
 			}
 			for i := range got {
-				if got[i] != tt.expected[i] {
-					t.Errorf("ExtractLinks(%q)[%d]: got %q, want %q", tt.body, i, got[i], tt.expected[i])
+				if got[i].Target != tt.expected[i] {
+					t.Errorf("ExtractLinks(%q)[%d]: got %q, want %q", tt.body, i, got[i].Target, tt.expected[i])
 				}
 			}
 		})
 	}
 }
 
+func TestParseLink(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Link
+	}{
+		{"Roadmap", Link{Target: "Roadmap"}},
+		{"Roadmap|the plan", Link{Target: "Roadmap", Label: "the plan"}},
+		{"Roadmap#Q3", Link{Target: "Roadmap", Heading: "Q3"}},
+		{"Roadmap#Q3 goals|goals", Link{Target: "Roadmap", Heading: "Q3 goals", Label: "goals"}},
+		{"Roadmap^abc123", Link{Target: "Roadmap", Block: "abc123"}},
+		{"Roadmap#^abc123", Link{Target: "Roadmap", Block: "abc123"}},
+		{"#Setup", Link{Heading: "Setup"}},
+		{" Spaced | label ", Link{Target: "Spaced", Label: "label"}},
+	}
+	for _, tt := range tests {
+		got := ParseLink(tt.in)
+		if got != tt.want {
+			t.Errorf("ParseLink(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+		if again := ParseLink(got.String()); again != got {
+			t.Errorf("ParseLink(%q) does not round-trip: %q", tt.in, got.String())
+		}
+	}
+}
+
 func TestBacklinks(t *testing.T) {
 	noteA := &Note{ID: "a", Title: "Alpha", Body: "Some text linking to [[Beta]]."}
 	noteB := &Note{ID: "b", Title: "Beta", Body: "Beta doesn't link back."}
@@ -177,7 +238,7 @@ func TestBacklinks(t *testing.T) {
 	all := []*Note{noteA, noteB, noteC}
 
 	t.Run("backlinks to Beta", func(t *testing.T) {
-		back := Backlinks("Beta", all)
+		back := Backlinks(noteB, all, NewResolver(all))
 		if len(back) != 2 {
 			t.Errorf("expected 2 backlinks to Beta, got %d", len(back))
 			return
@@ -192,14 +253,14 @@ func TestBacklinks(t *testing.T) {
 	})
 
 	t.Run("backlinks to Alpha", func(t *testing.T) {
-		back := Backlinks("Alpha", all)
+		back := Backlinks(noteA, all, NewResolver(all))
 		if len(back) != 1 || back[0].Title != "Gamma" {
 			t.Errorf("expected [Gamma] as backlinks to Alpha, got %v", back)
 		}
 	})
 
 	t.Run("no backlinks", func(t *testing.T) {
-		back := Backlinks("Gamma", all)
+		back := Backlinks(noteC, all, NewResolver(all))
 		if len(back) != 0 {
 			t.Errorf("expected no backlinks to Gamma, got %v", back)
 		}
@@ -218,8 +279,85 @@ func TestBuildFrontmatter(t *testing.T) {
 		t.Error("BuildFrontmatter returned empty string")
 	}
 	// Round-trip
-	meta, _ := ParseFrontmatter(fm + "body")
-	if meta["title"] != "Test" {
-		t.Errorf("round-trip title: got %q", meta["title"])
+	meta, _, _ := ParseFrontmatter(fm + "body")
+	if meta.Scalar("title") != "Test" {
+		t.Errorf("round-trip title: got %q", meta.Scalar("title"))
+	}
+}
+
+func TestFrontmatter_Set(t *testing.T) {
+	n := NoteFromRaw("n", "n.md", "---\ntitle: N\nstatus: draft # wip\n---\nbody", time.Now())
+	n.Extra.Set("status", &yaml.Node{Kind: yaml.ScalarNode, Value: "done"})
+	n.Extra.Set("source", &yaml.Node{Kind: yaml.ScalarNode, Value: "web"})
+	got := BuildFrontmatter(n)
+	if !strings.Contains(got, "status: done # wip\nsource: web\n") {
+		t.Errorf("frontmatter after Set:\n%s", got)
+	}
+}
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// stampRe matches the updated: line, which every save rewrites.
+var stampRe = regexp.MustCompile(`(?m)^updated: .*$`)
+
+// TestFrontmatterGolden saves each note in testdata/frontmatter and compares
+// the file with its .golden counterpart. Run with -update to regenerate.
+func TestFrontmatterGolden(t *testing.T) {
+	inputs, err := filepath.Glob("testdata/frontmatter/*.md")
+	if err != nil || len(inputs) == 0 {
+		t.Fatalf("no inputs: %v", err)
+	}
+	mtime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, in := range inputs {
+		name := strings.TrimSuffix(filepath.Base(in), ".md")
+		t.Run(name, func(t *testing.T) {
+			before, err := os.ReadFile(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dir := t.TempDir()
+			path := filepath.Join(dir, name+".md")
+			if err := os.WriteFile(path, before, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(path, mtime, mtime); err != nil {
+				t.Fatal(err)
+			}
+
+			s := NewStore(dir)
+			save := func() string {
+				n, err := s.Load(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := s.Save(n); err != nil {
+					t.Fatal(err)
+				}
+				after, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return stampRe.ReplaceAllString(string(after), "updated: STAMP")
+			}
+			got := save()
+
+			golden := strings.TrimSuffix(in, ".md") + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("after save:\n%s\nwant:\n%s", got, want)
+			}
+			if again := save(); again != got {
+				t.Errorf("second save changed the file:\n%s\nwant:\n%s", again, got)
+			}
+		})
 	}
 }
@@ -0,0 +1,28 @@
+package notes
+
+import (
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RecordAI adds model, the question asked and the time to n's "ai"
+// frontmatter list, so text the AI wrote into a note can be told apart
+// from the user's own.
+func RecordAI(n *Note, model, question string, at time.Time) {
+	str := func(s string) *yaml.Node {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}
+	}
+	entry := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+		str("model"), str(model),
+		str("question"), str(strings.Join(strings.Fields(question), " ")),
+		str("at"), {Kind: yaml.ScalarNode, Value: at.UTC().Format(time.RFC3339)},
+	}}
+	list := n.Extra.Get("ai")
+	if list == nil || list.Kind != yaml.SequenceNode {
+		list = &yaml.Node{Kind: yaml.SequenceNode}
+	}
+	list.Content = append(list.Content, entry)
+	n.Extra.Set("ai", list)
+}
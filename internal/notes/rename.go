@@ -0,0 +1,154 @@
+package notes
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// RenamePlan is what renaming a note changes, as worked out by PlanRename.
+type RenamePlan struct {
+	Note     *Note // the note being renamed
+	OldTitle string
+	NewTitle string
+	OldID    string
+	NewID    string     // the same as OldID if the file keeps its name
+	Body     string     // the note's own body, with self-links rewritten
+	Edits    []LinkEdit // other notes whose links change
+}
+
+// LinkEdit is a note whose wiki-links a rename rewrites.
+type LinkEdit struct {
+	Note  *Note
+	Body  string // the body with links rewritten
+	Count int    // how many links changed
+}
+
+// Links returns the total number of links the plan rewrites in other notes.
+func (p *RenamePlan) Links() int {
+	total := 0
+	for _, e := range p.Edits {
+		total += e.Count
+	}
+	return total
+}
+
+// PlanRename works out what Rename would do without writing anything.
+func (s *Store) PlanRename(id, newTitle string) (*RenamePlan, error) {
+	newTitle = strings.TrimSpace(newTitle)
+	if newTitle == "" {
+		return nil, fmt.Errorf("new title is empty")
+	}
+	all, err := s.LoadAll()
+	if err != nil {
+		return nil, err
+	}
+	var note *Note
+	for _, n := range all {
+		if n.ID == id {
+			note = n
+			break
+		}
+	}
+	if note == nil {
+		return nil, fmt.Errorf("no note with ID %q", id)
+	}
+
+	p := &RenamePlan{
+		Note:     note,
+		OldTitle: note.Title,
+		NewTitle: newTitle,
+		OldID:    note.ID,
+		NewID:    note.ID,
+		Body:     note.Body,
+	}
+	if slug := slugify(newTitle); path.Base(note.ID) != slug {
+		p.NewID = s.freeID(joinID(note.Folder, slug), note.ID)
+	}
+	for _, n := range all {
+		body, count := rewriteLinks(n.Body, note.Title, newTitle)
+		switch {
+		case n == note:
+			p.Body = body
+		case count > 0:
+			p.Edits = append(p.Edits, LinkEdit{Note: n, Body: body, Count: count})
+		}
+	}
+	return p, nil
+}
+
+// Rename gives the note id a new title, moves its file to match, and
+// rewrites [[links]] to the old title in every note. Links through an
+// alias are left alone, since the alias still resolves. It returns what
+// it changed; afterwards the plan's Note is the renamed note.
+//
+// If the note or a linking note changed on disk since it was loaded, the
+// error is a *ConflictError and that note is left as it is.
+func (s *Store) Rename(id, newTitle string) (*RenamePlan, error) {
+	p, err := s.PlanRename(id, newTitle)
+	if err != nil {
+		return nil, err
+	}
+	n := p.Note
+	oldPath := n.Filename
+	if err := s.checkDisk(n, oldPath); err != nil {
+		return nil, err
+	}
+
+	// The file moves, so carry its mode over
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(oldPath); err == nil {
+		perm = info.Mode().Perm()
+	}
+	n.Title = p.NewTitle
+	n.Body = p.Body
+	n.ID = p.NewID
+	n.Filename = s.pathFor(p.NewID)
+	if err := s.write(n, perm); err != nil {
+		return nil, err
+	}
+	if n.Filename != oldPath {
+		if err := os.Remove(oldPath); err != nil {
+			return p, err
+		}
+	}
+	var hookErr error
+	if p.NewID != p.OldID && s.onRename != nil {
+		hookErr = s.onRename(p.OldID, p.NewID)
+	}
+
+	for _, e := range p.Edits {
+		e.Note.Body = e.Body
+		if err := s.Save(e.Note); err != nil {
+			return p, fmt.Errorf("rewriting links in %s: %w", e.Note.ID, err)
+		}
+	}
+	if hookErr != nil {
+		return p, fmt.Errorf("moving data kept for %s: %w", p.OldID, hookErr)
+	}
+	return p, nil
+}
+
+// OnRename registers f to be called when Rename gives a note a new ID, so
+// data kept outside the vault under the note's ID can follow it.
+func (s *Store) OnRename(f func(oldID, newID string) error) {
+	s.onRename = f
+}
+
+// rewriteLinks points wiki-links to the note titled from at to instead,
+// keeping their headings, block refs and labels. It returns the new body
+// and how many links changed.
+func rewriteLinks(body, from, to string) (string, int) {
+	count := 0
+	out := wikiLinkRe.ReplaceAllStringFunc(body, func(m string) string {
+		l := ParseLink(m[2 : len(m)-2])
+		if !strings.EqualFold(l.Target, from) {
+			return m
+		}
+		count++
+		l.Target = to
+		return "[[" + l.String() + "]]"
+	})
+	return out, count
+}
@@ -0,0 +1,109 @@
+package notes
+
+import (
+	"os"
+	"testing"
+)
+
+func TestRewriteLinks(t *testing.T) {
+	body := "[[Old]] and [[old#Setup|setup]] and ![[Old^b1]] but not [[Older]] or [[#Old]]"
+	got, n := rewriteLinks(body, "Old", "New Name")
+	want := "[[New Name]] and [[New Name#Setup|setup]] and ![[New Name#^b1]] but not [[Older]] or [[#Old]]"
+	if got != want || n != 3 {
+		t.Errorf("got %q (%d), want %q (3)", got, n, want)
+	}
+}
+
+func TestStore_Rename(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir)
+
+	old, _ := s.CreateIn("projects", "Old Name", nil)
+	old.Body = "See [[Old Name#Intro]] above."
+	_ = s.Save(old)
+	_ = os.Chmod(old.Filename, 0600)
+	ref, _ := s.Create("Ref", nil)
+	ref.Body = "Links to [[Old Name]] and [[old name|that note]]."
+	_ = s.Save(ref)
+	other, _ := s.Create("Other", nil)
+	other.Body = "Nothing to see."
+	_ = s.Save(other)
+
+	p, err := s.PlanRename(old.ID, "New Name")
+	if err != nil {
+		t.Fatalf("PlanRename: %v", err)
+	}
+	if p.NewID != "projects/new-name" || len(p.Edits) != 1 || p.Edits[0].Note.ID != "ref" || p.Links() != 2 {
+		t.Fatalf("plan: %+v", p)
+	}
+	if _, err := os.Stat(s.pathFor("projects/new-name")); !os.IsNotExist(err) {
+		t.Fatal("PlanRename wrote to disk")
+	}
+
+	var hooked []string
+	s.OnRename(func(oldID, newID string) error {
+		hooked = append(hooked, oldID, newID)
+		return nil
+	})
+	if _, err := s.Rename(old.ID, "New Name"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	if len(hooked) != 2 || hooked[0] != "projects/old-name" || hooked[1] != "projects/new-name" {
+		t.Errorf("OnRename got %v", hooked)
+	}
+	if _, err := os.Stat(s.pathFor("projects/old-name")); !os.IsNotExist(err) {
+		t.Error("old file still exists")
+	}
+	moved, err := s.Load("projects/new-name")
+	if err != nil {
+		t.Fatalf("Load renamed: %v", err)
+	}
+	if moved.Title != "New Name" || moved.Body != "See [[New Name#Intro]] above." {
+		t.Errorf("renamed note: %q %q", moved.Title, moved.Body)
+	}
+	if info, _ := os.Stat(moved.Filename); info.Mode().Perm() != 0600 {
+		t.Errorf("renamed file mode: %v", info.Mode().Perm())
+	}
+	ref, _ = s.Load("ref")
+	if ref.Body != "Links to [[New Name]] and [[New Name|that note]]." {
+		t.Errorf("ref body: %q", ref.Body)
+	}
+}
+
+func TestStore_Rename_collisions(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir)
+	a, _ := s.Create("Alpha", nil)
+	_, _ = s.Create("Beta", nil)
+
+	p, err := s.PlanRename(a.ID, "Beta")
+	if err != nil || p.NewID != "beta-2" {
+		t.Fatalf("expected beta-2, got %+v, %v", p, err)
+	}
+
+	// Case-only changes keep the file
+	p, _ = s.PlanRename(a.ID, "ALPHA")
+	if p.NewID != "alpha" {
+		t.Errorf("expected alpha, got %q", p.NewID)
+	}
+
+	// A suffixed note renamed to its base title keeps its own ID
+	b2, _ := s.Create("Beta", nil)
+	p, _ = s.PlanRename(b2.ID, "BETA")
+	if b2.ID != "beta-2" || p.NewID != "beta-2" {
+		t.Errorf("expected beta-2 to stay, got %q -> %q", b2.ID, p.NewID)
+	}
+
+	// Rename reloads the vault, so edits made after a preview are kept
+	ref, _ := s.Create("Ref", nil)
+	ref.Body = "[[Alpha]]"
+	_ = s.Save(ref)
+	_ = os.WriteFile(ref.Filename, []byte("edited elsewhere [[Alpha]]"), 0644)
+	if _, err := s.Rename(a.ID, "Gamma"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	ref, _ = s.Load("ref")
+	if ref.Body != "edited elsewhere [[Gamma]]" {
+		t.Errorf("ref body: %q", ref.Body)
+	}
+}
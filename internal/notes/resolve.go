@@ -0,0 +1,90 @@
+package notes
+
+import (
+	"sort"
+	"strings"
+)
+
+// Resolver finds the note a wiki-link target refers to, by title or by one
+// of the note's aliases. Matching ignores case. When a title and an alias
+// collide the title wins; when two notes claim the same alias the first
+// note in the list given to NewResolver wins and the clash is reported in
+// Conflicts.
+type Resolver struct {
+	byTitle   map[string]*Note
+	byAlias   map[string]*Note
+	Conflicts []AliasConflict
+}
+
+// AliasConflict is an alias claimed by more than one note, either as an
+// alias or as a title.
+type AliasConflict struct {
+	Alias string
+	Notes []*Note
+}
+
+// NewResolver indexes the titles and aliases of all.
+func NewResolver(all []*Note) *Resolver {
+	r := &Resolver{byTitle: map[string]*Note{}, byAlias: map[string]*Note{}}
+	for _, n := range all {
+		key := strings.ToLower(n.Title)
+		if _, ok := r.byTitle[key]; !ok {
+			r.byTitle[key] = n
+		}
+	}
+
+	claims := map[string][]*Note{}
+	var order []string
+	for _, n := range all {
+		for _, a := range n.Aliases {
+			key := strings.ToLower(a)
+			if len(claims[key]) == 0 {
+				order = append(order, a)
+			}
+			if !containsNote(claims[key], n) {
+				claims[key] = append(claims[key], n)
+			}
+			if _, ok := r.byAlias[key]; !ok {
+				r.byAlias[key] = n
+			}
+		}
+	}
+	for _, a := range order {
+		key := strings.ToLower(a)
+		owners := claims[key]
+		if t, ok := r.byTitle[key]; ok && !containsNote(owners, t) {
+			owners = append([]*Note{t}, owners...)
+		}
+		if len(owners) > 1 {
+			r.Conflicts = append(r.Conflicts, AliasConflict{Alias: a, Notes: owners})
+		}
+	}
+	sort.SliceStable(r.Conflicts, func(i, j int) bool {
+		return strings.ToLower(r.Conflicts[i].Alias) < strings.ToLower(r.Conflicts[j].Alias)
+	})
+	return r
+}
+
+// Resolve returns the note target names, or nil if there is none.
+func (r *Resolver) Resolve(target string) *Note {
+	key := strings.ToLower(strings.TrimSpace(target))
+	if n, ok := r.byTitle[key]; ok {
+		return n
+	}
+	return r.byAlias[key]
+}
+
+// Names returns the title and aliases of n: every name a wiki-link to it
+// may use.
+func Names(n *Note) []string {
+	return append([]string{n.Title}, n.Aliases...)
+}
+
+func containsNote(ns []*Note, n *Note) bool {
+	for _, m := range ns {
+		if m == n {
+			return true
+		}
+	}
+	return false
+}
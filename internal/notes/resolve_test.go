@@ -0,0 +1,58 @@
+package notes
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolver(t *testing.T) {
+	k8s := &Note{ID: "k8s", Title: "Kubernetes", Aliases: []string{"K8s", "kube"}}
+	docker := &Note{ID: "docker", Title: "Docker", Aliases: []string{"containers"}}
+	podman := &Note{ID: "podman", Title: "Podman", Aliases: []string{"Containers"}}
+	kube := &Note{ID: "kube", Title: "Kube"}
+	r := NewResolver([]*Note{k8s, docker, podman, kube})
+
+	tests := []struct {
+		target string
+		want   *Note
+	}{
+		{"Kubernetes", k8s},
+		{"k8s", k8s},
+		{" K8S ", k8s},
+		{"kube", kube}, // a title beats another note's alias
+		{"containers", docker},
+		{"Missing", nil},
+	}
+	for _, tt := range tests {
+		if got := r.Resolve(tt.target); got != tt.want {
+			t.Errorf("Resolve(%q) = %v, want %v", tt.target, got, tt.want)
+		}
+	}
+
+	if len(r.Conflicts) != 2 {
+		t.Fatalf("expected 2 conflicts, got %+v", r.Conflicts)
+	}
+	if c := r.Conflicts[0]; c.Alias != "containers" || len(c.Notes) != 2 {
+		t.Errorf("conflict 0: %+v", c)
+	}
+	if c := r.Conflicts[1]; c.Alias != "kube" || c.Notes[0] != kube || c.Notes[1] != k8s {
+		t.Errorf("conflict 1: %+v", c)
+	}
+}
+
+func TestBacklinks_aliases(t *testing.T) {
+	k8s := NoteFromRaw("k8s", "", "---\ntitle: Kubernetes\naliases: [K8s]\n---\n\nbody", time.Time{})
+	a := &Note{ID: "a", Title: "A", Body: "deployed on [[k8s]]"}
+	b := &Note{ID: "b", Title: "B", Body: "see [[Kubernetes]]"}
+	c := &Note{ID: "c", Title: "C", Body: "nothing [[K8s cluster]]"}
+	// d's alias K8s loses to k8s, which comes first, as in the graph.
+	d := NoteFromRaw("d", "", "---\ntitle: D\naliases: [k8s]\n---\n\n[[Kubernetes]] again", time.Time{})
+	all := []*Note{k8s, a, b, c, d}
+	back := Backlinks(k8s, all, NewResolver(all))
+	if len(back) != 3 || back[0] != a || back[1] != b || back[2] != d {
+		t.Errorf("got %v", back)
+	}
+	if back := Backlinks(d, all, NewResolver(all)); len(back) != 0 {
+		t.Errorf("links resolved to another note should not count: %v", back)
+	}
+}
@@ -0,0 +1,119 @@
+package notes
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Period is the span of days a review covers.
+type Period struct {
+	Start time.Time // midnight on the first day
+	End   time.Time // midnight after the last day
+	ID    string    // the review note's ID, e.g. "weekly-2026-W42"
+	Title string    // the review note's title, e.g. "Weekly 2026-W42"
+	Name  string    // how the period is described, e.g. "the week of 12 Oct 2026"
+}
+
+// reviewIDRe matches the IDs Week and Month give review notes, which
+// reviews leave out.
+var reviewIDRe = regexp.MustCompile(`^(weekly-\d{4}-W\d{2}|monthly-\d{4}-\d{2})$`)
+
+// Week returns the ISO week (Monday to Sunday) containing t.
+func Week(t time.Time) Period {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	year, week := start.ISOWeek()
+	label := fmt.Sprintf("%d-W%02d", year, week)
+	return Period{
+		Start: start,
+		End:   start.AddDate(0, 0, 7),
+		ID:    "weekly-" + label,
+		Title: "Weekly " + label,
+		Name:  "the week of " + start.Format("2 Jan 2006"),
+	}
+}
+
+// Month returns the calendar month containing t.
+func Month(t time.Time) Period {
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	label := start.Format("2006-01")
+	return Period{
+		Start: start,
+		End:   start.AddDate(0, 1, 0),
+		ID:    "monthly-" + label,
+		Title: "Monthly " + label,
+		Name:  start.Format("January 2006"),
+	}
+}
+
+// ParsePeriod returns the week (kind "week") or month ("month") that s
+// names: a date (YYYY-MM-DD), or YYYY-Www for a week or YYYY-MM for a
+// month. An empty s means the one containing now.
+func ParsePeriod(kind, s string, now time.Time) (Period, error) {
+	of := Week
+	if kind == "month" {
+		of = Month
+	}
+	if s == "" {
+		return of(now), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return of(t), nil
+	}
+	var year, n int
+	if kind == "week" {
+		if _, err := fmt.Sscanf(strings.ToUpper(s), "%4d-W%d", &year, &n); err == nil && n >= 1 && n <= 53 {
+			// 4 January is always in week 1
+			p := Week(time.Date(year, 1, 4, 0, 0, 0, 0, now.Location()).AddDate(0, 0, 7*(n-1)))
+			if y, w := p.Start.ISOWeek(); y == year && w == n {
+				return p, nil
+			}
+		}
+		return Period{}, fmt.Errorf("bad week %q (want YYYY-MM-DD or YYYY-Www)", s)
+	}
+	if t, err := time.ParseInLocation("2006-01", s, now.Location()); err == nil {
+		return Month(t), nil
+	}
+	return Period{}, fmt.Errorf("bad month %q (want YYYY-MM-DD or YYYY-MM)", s)
+}
+
+// DailyDate returns the day a daily note (ID daily-YYYY-MM-DD) is for.
+func DailyDate(n *Note) (time.Time, bool) {
+	s, ok := strings.CutPrefix(n.ID, "daily-")
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	return t, err == nil
+}
+
+// Notes returns what a review of p covers: the daily notes for days in p,
+// oldest first, then the other notes updated during p, most recent first.
+// Earlier reviews are left out.
+func (p Period) Notes(all []*Note) []*Note {
+	var daily, other []*Note
+	for _, n := range all {
+		if isReview(n) {
+			continue
+		}
+		if t, ok := DailyDate(n); ok {
+			if !t.Before(p.Start) && t.Before(p.End) {
+				daily = append(daily, n)
+			}
+			continue
+		}
+		if !n.Updated.Before(p.Start) && n.Updated.Before(p.End) {
+			other = append(other, n)
+		}
+	}
+	sort.Slice(daily, func(i, j int) bool { return daily[i].ID < daily[j].ID })
+	sort.SliceStable(other, func(i, j int) bool { return other[i].Updated.After(other[j].Updated) })
+	return append(daily, other...)
+}
+
+func isReview(n *Note) bool {
+	return reviewIDRe.MatchString(n.ID)
+}
@@ -0,0 +1,59 @@
+package notes
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePeriod(t *testing.T) {
+	now := time.Date(2026, 10, 16, 15, 0, 0, 0, time.Local)
+	tests := []struct {
+		kind, s, id, start string
+	}{
+		{"week", "", "weekly-2026-W42", "2026-10-12"},
+		{"week", "2026-10-18", "weekly-2026-W42", "2026-10-12"},
+		{"week", "2026-w01", "weekly-2026-W01", "2025-12-29"},
+		{"week", "2021-01-03", "weekly-2020-W53", "2020-12-28"},
+		{"month", "", "monthly-2026-10", "2026-10-01"},
+		{"month", "2026-02-14", "monthly-2026-02", "2026-02-01"},
+		{"month", "2026-03", "monthly-2026-03", "2026-03-01"},
+	}
+	for _, tt := range tests {
+		p, err := ParsePeriod(tt.kind, tt.s, now)
+		if err != nil || p.ID != tt.id || p.Start.Format("2006-01-02") != tt.start {
+			t.Errorf("ParsePeriod(%s, %q) = %s from %s, %v; want %s from %s", tt.kind, tt.s, p.ID, p.Start.Format("2006-01-02"), err, tt.id, tt.start)
+		}
+	}
+	for _, bad := range []string{"2026-W54", "2026-W00", "last week", "2026-13"} {
+		if _, err := ParsePeriod("week", bad, now); err == nil {
+			t.Errorf("ParsePeriod(week, %q) succeeded", bad)
+		}
+	}
+	if p, _ := ParsePeriod("month", "2026-02", now); !p.End.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("February ends %v", p.End)
+	}
+}
+
+func TestPeriod_Notes(t *testing.T) {
+	at := func(day int) time.Time { return time.Date(2026, 10, day, 12, 0, 0, 0, time.Local) }
+	all := []*Note{
+		{ID: "daily-2026-10-14", Updated: at(20)}, // edited later, still this week's
+		{ID: "daily-2026-10-12", Updated: at(12)},
+		{ID: "daily-2026-10-11", Updated: at(12)}, // the week before
+		{ID: "atlas", Updated: at(13)},
+		{ID: "projects/plan", Updated: at(15)},
+		{ID: "old", Updated: at(1)},
+		{ID: "weekly-2026-W41", Updated: at(12)},
+		{ID: "monthly-2026-10", Updated: at(14)},
+		{ID: "weekly-sync", Updated: at(14)}, // an ordinary note
+	}
+	var got []string
+	for _, n := range Week(at(16)).Notes(all) {
+		got = append(got, n.ID)
+	}
+	want := "daily-2026-10-12 daily-2026-10-14 projects/plan weekly-sync atlas"
+	if s := strings.Join(got, " "); s != want {
+		t.Errorf("Notes = %s, want %s", s, want)
+	}
+}
@@ -2,15 +2,23 @@ package notes
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
 type Store struct {
 	dir string
+
+	mu  sync.Mutex // guards idx
+	idx *index     // nil unless EnableIndex was called
+
+	onRename func(oldID, newID string) error // see OnRename
 }
 
 func NewStore(dir string) *Store {
@@ -21,23 +29,55 @@ func (s *Store) Dir() string {
 	return s.dir
 }
 
+// LoadAll reads every note in the vault, descending into subfolders.
+// Hidden directories (.git, .obsidian, ...) are skipped.
 func (s *Store) LoadAll() ([]*Note, error) {
-	entries, err := os.ReadDir(s.dir)
-	if err != nil {
+	if _, err := os.Stat(s.dir); err != nil {
 		return nil, err
 	}
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seen := map[string]bool{}
+	if s.idx != nil {
+		s.idx.load(s.dir)
+	}
+
 	var notes []*Note
-	for _, e := range entries {
-		if e.IsDir() || !strings.HasSuffix(e.Name(), ".md") {
-			continue
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable subfolder: skip it rather than failing the whole vault.
+			if d != nil && d.IsDir() && path != s.dir {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			if path != s.dir && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".md") {
+			return nil
 		}
 
-		note, err := s.loadFile(filepath.Join(s.dir, e.Name()))
+		note, err := s.loadIndexed(path, d)
 		if err != nil {
-			continue
+			return nil
 		}
+		seen[note.ID] = true
 		notes = append(notes, note)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.idx != nil {
+		s.idx.prune(seen)
+		// The index is only a cache; failing to write it is not an error.
+		_ = s.idx.save(s.dir)
 	}
 
 	// Sort by updated time, newest first
@@ -48,8 +88,47 @@ func (s *Store) LoadAll() ([]*Note, error) {
 	return notes, nil
 }
 
+// Load reads a single note. Notes in subfolders have IDs of the form
+// "folder/sub/name".
 func (s *Store) Load(id string) (*Note, error) {
-	return s.loadFile(filepath.Join(s.dir, id+".md"))
+	return s.loadFile(s.pathFor(id))
+}
+
+func (s *Store) pathFor(id string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(id)+".md")
+}
+
+// loadIndexed returns the note at path, from the index if the file is
+// unchanged since it was cached. Callers must hold s.mu.
+func (s *Store) loadIndexed(path string, d fs.DirEntry) (*Note, error) {
+	if s.idx == nil {
+		return s.loadFile(path)
+	}
+	info, err := d.Info()
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.idFor(path)
+	if err != nil {
+		return nil, err
+	}
+	if note, ok := s.idx.lookup(id, path, info); ok {
+		return note, nil
+	}
+	note, err := s.loadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s.idx.put(note, info)
+	return note, nil
+}
+
+func (s *Store) idFor(path string) (string, error) {
+	rel, err := filepath.Rel(s.dir, path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(filepath.ToSlash(rel), ".md"), nil
 }
 
 func (s *Store) loadFile(path string) (*Note, error) {
@@ -63,38 +142,96 @@ func (s *Store) loadFile(path string) (*Note, error) {
 		return nil, err
 	}
 
-	id := strings.TrimSuffix(filepath.Base(path), ".md")
-	return NoteFromRaw(id, path, string(data), info.ModTime()), nil
+	id, err := s.idFor(path)
+	if err != nil {
+		return nil, err
+	}
+	note := NoteFromRaw(id, path, string(data), info.ModTime())
+	note.diskHash = contentHash(data)
+	return note, nil
 }
 
+// Save writes note to disk. If the file was modified by something else since
+// the note was loaded, Save writes nothing and returns a *ConflictError;
+// use Overwrite to save anyway.
 func (s *Store) Save(note *Note) error {
+	if err := s.checkDisk(note, note.Filename); err != nil {
+		return err
+	}
+	return s.Overwrite(note)
+}
+
+// checkDisk returns a *ConflictError if the file at path no longer holds
+// the content note was loaded from.
+func (s *Store) checkDisk(note *Note, path string) error {
+	if note.diskHash == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || contentHash(data) == note.diskHash {
+		return nil
+	}
+	disk, err := s.loadFile(path)
+	if err != nil {
+		return err
+	}
+	return &ConflictError{Note: note, Disk: disk}
+}
+
+// Overwrite writes note to disk unconditionally, discarding any external
+// changes made since it was loaded.
+func (s *Store) Overwrite(note *Note) error {
+	return s.write(note, 0644)
+}
+
+// write saves note to its file, which gets perm if it is new.
+func (s *Store) write(note *Note, perm os.FileMode) error {
+	if note.frontErr != nil {
+		return fmt.Errorf("%s: %w; fix it by hand", note.ID, note.frontErr)
+	}
 	note.Updated = time.Now()
 	content := BuildFrontmatter(note) + note.Body
+	if err := writeFileAtomic(note.Filename, []byte(content), perm); err != nil {
+		return err
+	}
 	note.Raw = content
-	return os.WriteFile(note.Filename, []byte(content), 0644)
+	note.diskHash = contentHash([]byte(content))
+	note.Links = ExtractLinks(note.Body)
+	note.Words = len(strings.Fields(note.Body))
+	return nil
 }
 
+// Create makes a new note at the top level of the vault.
 func (s *Store) Create(title string, tags []string) (*Note, error) {
-	id := slugify(title)
-	// Avoid collisions
-	base := id
-	for i := 2; ; i++ {
-		path := filepath.Join(s.dir, id+".md")
-		if _, err := os.Stat(path); os.IsNotExist(err) {
-			break
+	return s.CreateIn("", title, tags)
+}
+
+// CreateIn makes a new note inside folder (a slash-separated path relative
+// to the notes dir), creating the folder if needed. An empty folder means
+// the top level.
+func (s *Store) CreateIn(folder, title string, tags []string) (*Note, error) {
+	folder, err := cleanFolder(folder)
+	if err != nil {
+		return nil, err
+	}
+	if folder != "" {
+		if err := os.MkdirAll(filepath.Join(s.dir, filepath.FromSlash(folder)), 0755); err != nil {
+			return nil, err
 		}
-		id = fmt.Sprintf("%s-%d", base, i)
 	}
 
+	id := s.freeID(joinID(folder, slugify(title)), "")
+
 	now := time.Now()
 	note := &Note{
 		ID:       id,
 		Title:    title,
 		Tags:     tags,
+		Folder:   folder,
 		Created:  now,
 		Updated:  now,
 		Body:     "",
-		Filename: filepath.Join(s.dir, id+".md"),
+		Filename: s.pathFor(id),
 	}
 
 	if err := s.Save(note); err != nil {
@@ -103,6 +240,22 @@ func (s *Store) Create(title string, tags []string) (*Note, error) {
 	return note, nil
 }
 
+// freeID returns id, or id with a -2, -3… suffix if a note already has it.
+// The note self, if any, doesn't count: a note being renamed may keep its
+// own ID.
+func (s *Store) freeID(id, self string) string {
+	base := id
+	for i := 2; ; i++ {
+		if id == self {
+			return id
+		}
+		if _, err := os.Stat(s.pathFor(id)); os.IsNotExist(err) {
+			return id
+		}
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
 func (s *Store) CreateDaily() (*Note, error) {
 	today := time.Now().Format("2006-01-02")
 	id := "daily-" + today
@@ -115,14 +268,73 @@ func (s *Store) CreateDaily() (*Note, error) {
 	return s.Create("Daily "+today, []string{"daily"})
 }
 
+// LoadOrCreate returns the note id, first creating it with title and tags
+// if there is no such note. Unlike CreateIn, the ID is used as given.
+func (s *Store) LoadOrCreate(id, title string, tags []string) (*Note, error) {
+	path := s.pathFor(id)
+	if _, err := os.Stat(path); err == nil {
+		return s.loadFile(path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	note := &Note{
+		ID:       id,
+		Title:    title,
+		Tags:     tags,
+		Folder:   ParentFolder(id),
+		Created:  now,
+		Updated:  now,
+		Filename: path,
+	}
+	if err := s.Save(note); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
 func (s *Store) Delete(id string) error {
-	return os.Remove(filepath.Join(s.dir, id+".md"))
+	return os.Remove(s.pathFor(id))
 }
 
 func (s *Store) Reload(note *Note) (*Note, error) {
 	return s.loadFile(note.Filename)
 }
 
+// cleanFolder normalizes a user-supplied folder path and rejects anything
+// that would escape the notes dir.
+func cleanFolder(folder string) (string, error) {
+	folder = strings.Trim(filepath.ToSlash(strings.TrimSpace(folder)), "/")
+	if folder == "" {
+		return "", nil
+	}
+	folder = path.Clean(folder)
+	if folder == "." {
+		return "", nil
+	}
+	if folder == ".." || strings.HasPrefix(folder, "../") {
+		return "", fmt.Errorf("folder %q is outside the notes dir", folder)
+	}
+	return folder, nil
+}
+
+func joinID(folder, name string) string {
+	if folder == "" {
+		return name
+	}
+	return folder + "/" + name
+}
+
+// ParentFolder returns the folder containing folder, or "" for a
+// top-level one.
+func ParentFolder(folder string) string {
+	if i := strings.LastIndex(folder, "/"); i != -1 {
+		return folder[:i]
+	}
+	return ""
+}
+
 func slugify(title string) string {
 	s := strings.ToLower(title)
 	var out strings.Builder
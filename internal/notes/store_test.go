@@ -1,6 +1,7 @@
 package notes
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -124,3 +125,108 @@ func TestStore_IgnoresNonMarkdown(t *testing.T) {
 		t.Errorf("expected 1 note, got %d", len(all))
 	}
 }
+
+func TestStore_LoadAll_nested(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir)
+
+	_ = os.MkdirAll(filepath.Join(dir, "projects", "atlas"), 0755)
+	_ = os.MkdirAll(filepath.Join(dir, ".git"), 0755)
+	_ = os.WriteFile(filepath.Join(dir, "top.md"), []byte("---\ntitle: Top\n---\n"), 0644)
+	_ = os.WriteFile(filepath.Join(dir, "projects", "atlas", "kickoff.md"), []byte("---\ntitle: Kickoff\n---\n"), 0644)
+	_ = os.WriteFile(filepath.Join(dir, ".git", "hidden.md"), []byte("ignore me"), 0644)
+
+	all, err := s.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("expected 2 notes, got %d", len(all))
+	}
+
+	byTitle := map[string]*Note{}
+	for _, n := range all {
+		byTitle[n.Title] = n
+	}
+	k := byTitle["Kickoff"]
+	if k == nil {
+		t.Fatal("nested note not loaded")
+	}
+	if k.ID != "projects/atlas/kickoff" {
+		t.Errorf("nested ID: got %q", k.ID)
+	}
+	if k.Folder != "projects/atlas" {
+		t.Errorf("nested Folder: got %q", k.Folder)
+	}
+	if byTitle["Top"].Folder != "" {
+		t.Errorf("top-level Folder: got %q", byTitle["Top"].Folder)
+	}
+
+	loaded, err := s.Load(k.ID)
+	if err != nil {
+		t.Fatalf("Load nested: %v", err)
+	}
+	if loaded.Title != "Kickoff" {
+		t.Errorf("Load nested title: got %q", loaded.Title)
+	}
+
+	if ParentFolder(k.Folder) != "projects" || ParentFolder("projects") != "" {
+		t.Errorf("ParentFolder: got %q", ParentFolder(k.Folder))
+	}
+}
+
+func TestStore_CreateIn(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir)
+
+	note, err := s.CreateIn("people/", "Ada Lovelace", nil)
+	if err != nil {
+		t.Fatalf("CreateIn: %v", err)
+	}
+	if note.ID != "people/ada-lovelace" {
+		t.Errorf("ID: got %q", note.ID)
+	}
+	if note.Folder != "people" {
+		t.Errorf("Folder: got %q", note.Folder)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "people", "ada-lovelace.md")); err != nil {
+		t.Errorf("file not written in folder: %v", err)
+	}
+
+	n2, _ := s.CreateIn("people", "Ada Lovelace", nil)
+	if n2.ID != "people/ada-lovelace-2" {
+		t.Errorf("collision ID in folder: got %q", n2.ID)
+	}
+
+	if err := s.Delete(note.ID); err != nil {
+		t.Errorf("Delete nested: %v", err)
+	}
+}
+
+func TestStore_CreateIn_rejectsEscape(t *testing.T) {
+	s := NewStore(t.TempDir())
+	if _, err := s.CreateIn("../outside", "Nope", nil); err == nil {
+		t.Error("expected error for folder outside the notes dir")
+	}
+}
+
+func TestStore_Save_refusesUnreadableFrontmatter(t *testing.T) {
+	dir := t.TempDir()
+	raw := "---\ntitle: Plan\nproject:\n  name: Q3: plan\n---\n\nbody\n"
+	path := filepath.Join(dir, "plan.md")
+	_ = os.WriteFile(path, []byte(raw), 0644)
+
+	s := NewStore(dir)
+	n, err := s.Load("plan")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	n.Body = "changed\n"
+	var fe *FrontmatterError
+	if err := s.Save(n); !errors.As(err, &fe) {
+		t.Fatalf("Save: got %v, want a *FrontmatterError", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != raw {
+		t.Errorf("file was rewritten:\n%s", data)
+	}
+}
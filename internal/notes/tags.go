@@ -0,0 +1,72 @@
+package notes
+
+import (
+	"sort"
+	"strings"
+)
+
+// TagCount is a tag and how many notes use it.
+type TagCount struct {
+	Tag   string
+	Notes int
+}
+
+// Vocabulary returns every tag used in all, most used first, then by name.
+// Tags differing only in case count as one, spelled as most notes spell it.
+func Vocabulary(all []*Note) []TagCount {
+	counts := map[string]map[string]int{} // lowercased -> spelling -> notes
+	for _, n := range all {
+		seen := map[string]bool{}
+		for _, t := range n.Tags {
+			key := strings.ToLower(t)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if counts[key] == nil {
+				counts[key] = map[string]int{}
+			}
+			counts[key][t]++
+		}
+	}
+	out := make([]TagCount, 0, len(counts))
+	for _, spellings := range counts {
+		var tc TagCount
+		best := 0
+		for t, c := range spellings {
+			tc.Notes += c
+			if c > best || (c == best && t < tc.Tag) {
+				tc.Tag, best = t, c
+			}
+		}
+		out = append(out, tc)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Notes != out[j].Notes {
+			return out[i].Notes > out[j].Notes
+		}
+		return out[i].Tag < out[j].Tag
+	})
+	return out
+}
+
+// HasTag reports whether n is tagged tag, ignoring case.
+func HasTag(n *Note, tag string) bool {
+	for _, t := range n.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Untagged returns the notes in all that have no tags.
+func Untagged(all []*Note) []*Note {
+	var out []*Note
+	for _, n := range all {
+		if len(n.Tags) == 0 {
+			out = append(out, n)
+		}
+	}
+	return out
+}
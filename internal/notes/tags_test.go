@@ -0,0 +1,21 @@
+package notes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVocabulary(t *testing.T) {
+	all := []*Note{
+		{Tags: []string{"work", "Ideas"}},
+		{Tags: []string{"ideas", "work", "work"}},
+		{Tags: []string{"ideas", "atlas"}},
+	}
+	want := []TagCount{{"ideas", 3}, {"work", 2}, {"atlas", 1}}
+	if got := Vocabulary(all); !reflect.DeepEqual(got, want) {
+		t.Errorf("Vocabulary = %+v, want %+v", got, want)
+	}
+	if !HasTag(all[0], "IDEAS") || HasTag(all[0], "atlas") {
+		t.Error("HasTag")
+	}
+}
@@ -0,0 +1,155 @@
+package notes
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long the watcher waits for a burst of events to
+// settle. Editors and sync tools often write, rename and chmod a file in
+// quick succession; they should produce one notification, not four.
+const watchDebounce = 150 * time.Millisecond
+
+// Watcher reports changes to the notes on disk. It watches the notes dir and
+// every (non-hidden) subfolder, and starts watching new subfolders as they
+// appear.
+type Watcher struct {
+	// Changes receives the IDs of notes that were created, modified or
+	// removed. A removed or renamed folder is reported by its folder path.
+	// The channel is closed when the watcher is closed.
+	Changes chan []string
+
+	store *Store
+	fsw   *fsnotify.Watcher
+	done  chan struct{}
+}
+
+// Watch starts watching the vault for changes.
+func (s *Store) Watch() (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		Changes: make(chan []string),
+		store:   s,
+		fsw:     fsw,
+		done:    make(chan struct{}),
+	}
+	if err := w.addTree(s.dir); err != nil {
+		_ = fsw.Close()
+		return nil, err
+	}
+	go w.run()
+	return w, nil
+}
+
+// Close stops the watcher and closes Changes.
+func (w *Watcher) Close() error {
+	close(w.done)
+	return w.fsw.Close()
+}
+
+// addTree watches dir and all non-hidden folders below it.
+func (w *Watcher) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if path != w.store.dir && strings.HasPrefix(d.Name(), ".") {
+			return fs.SkipDir
+		}
+		return w.fsw.Add(path)
+	})
+}
+
+func (w *Watcher) run() {
+	defer close(w.Changes)
+
+	pending := map[string]bool{}
+	var timer *time.Timer
+	var fire <-chan time.Time
+
+	for {
+		select {
+		case ev, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			id, ok := w.handle(ev)
+			if !ok {
+				continue
+			}
+			pending[id] = true
+			if timer == nil {
+				timer = time.NewTimer(watchDebounce)
+			} else {
+				timer.Reset(watchDebounce)
+			}
+			fire = timer.C
+
+		case _, ok := <-w.fsw.Errors:
+			// Errors here are overflow or a watched folder going away;
+			// the next event will trigger a full reload anyway.
+			if !ok {
+				return
+			}
+
+		case <-fire:
+			fire = nil
+			ids := make([]string, 0, len(pending))
+			for id := range pending {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+			pending = map[string]bool{}
+			select {
+			case w.Changes <- ids:
+			case <-w.done:
+				return
+			}
+
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// handle decides whether ev concerns the vault and returns the note ID (or
+// folder path) it refers to.
+func (w *Watcher) handle(ev fsnotify.Event) (string, bool) {
+	rel, err := filepath.Rel(w.store.dir, ev.Name)
+	if err != nil {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, part := range strings.Split(rel, "/") {
+		if strings.HasPrefix(part, ".") {
+			return "", false // hidden folders and our own temp files
+		}
+	}
+
+	if strings.HasSuffix(rel, ".md") {
+		return strings.TrimSuffix(rel, ".md"), true
+	}
+
+	// A folder appeared: watch it, and report it since it may already
+	// contain notes (e.g. moved in from elsewhere).
+	if ev.Has(fsnotify.Create) {
+		if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+			_ = w.addTree(ev.Name)
+			return rel, true
+		}
+	}
+	// A folder went away; fsnotify drops its watch on its own.
+	if ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename) {
+		return rel, true
+	}
+	return "", false
+}
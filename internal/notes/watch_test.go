@@ -0,0 +1,65 @@
+package notes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func waitForChange(t *testing.T, w *Watcher, want string) {
+	t.Helper()
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case ids := <-w.Changes:
+			for _, id := range ids {
+				if id == want {
+					return
+				}
+			}
+		case <-timeout:
+			t.Fatalf("no change reported for %q", want)
+		}
+	}
+}
+
+func TestWatcher_reportsChanges(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir)
+	w, err := s.Watch()
+	if err != nil {
+		t.Skipf("filesystem watching unavailable: %v", err)
+	}
+	defer w.Close()
+
+	note, _ := s.Create("Watched", nil)
+	waitForChange(t, w, note.ID)
+
+	// A folder created after the watcher started is picked up too.
+	_ = os.MkdirAll(filepath.Join(dir, "projects"), 0755)
+	waitForChange(t, w, "projects")
+	_ = os.WriteFile(filepath.Join(dir, "projects", "atlas.md"), []byte("# Atlas"), 0644)
+	waitForChange(t, w, "projects/atlas")
+
+	_ = s.Delete(note.ID)
+	waitForChange(t, w, note.ID)
+}
+
+func TestWatcher_ignoresHiddenAndTemp(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir)
+	w, err := s.Watch()
+	if err != nil {
+		t.Skipf("filesystem watching unavailable: %v", err)
+	}
+	defer w.Close()
+
+	_ = os.WriteFile(filepath.Join(dir, ".grove-tmp-123"), []byte("x"), 0644)
+	_ = os.WriteFile(filepath.Join(dir, "visible.md"), []byte("x"), 0644)
+
+	ids := <-w.Changes
+	if len(ids) != 1 || ids[0] != "visible" {
+		t.Errorf("expected only [visible], got %v", ids)
+	}
+}
@@ -0,0 +1,331 @@
+// Package search provides ranked full-text search over notes.
+//
+// Notes are tokenized into an inverted index and scored with BM25. Queries
+// combine text (bare terms, "quoted phrases", prefix*) with field filters
+// and boolean operators; see Query for the syntax.
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/yash-srivastava19/grove/internal/notes"
+)
+
+// BM25 parameters. These are the usual defaults.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Field weights: a term in the title counts as much as three in the body.
+const (
+	titleWeight = 3
+	tagWeight   = 2
+	bodyWeight  = 1
+)
+
+// fieldGap separates fields in the position space so phrases never match
+// across the end of the title and the start of the body.
+const fieldGap = 1000
+
+// maxSnippets is how many matching lines are reported per result.
+const maxSnippets = 3
+
+// Index is an inverted index over a fixed set of notes.
+type Index struct {
+	notes    []*notes.Note
+	resolver *notes.Resolver
+	postings map[string][]posting // term -> one posting per note, by doc
+	docLen   []float64            // weighted token count per note
+	avgLen   float64
+	lineOf   []map[int]int // per note: token position -> file line (body only)
+	lines    [][]string    // per note: body lines
+	offset   []int         // per note: file line number of the first body line
+}
+
+type posting struct {
+	doc       int
+	tf        float64 // weighted term frequency
+	positions []int
+}
+
+// Result is one matching note.
+type Result struct {
+	Note     *notes.Note
+	Score    float64
+	Snippets []Snippet
+}
+
+// Snippet is a matching line from a note's body.
+type Snippet struct {
+	Line int    // 1-based line number in the note's file
+	Text string // the line, trimmed
+}
+
+// Build indexes all. The index keeps references to the notes, so rebuild it
+// whenever the set of notes changes.
+func Build(all []*notes.Note) *Index {
+	ix := &Index{
+		notes:    all,
+		resolver: notes.NewResolver(all),
+		postings: map[string][]posting{},
+		docLen:   make([]float64, len(all)),
+		lineOf:   make([]map[int]int, len(all)),
+		lines:    make([][]string, len(all)),
+		offset:   make([]int, len(all)),
+	}
+
+	var total float64
+	for doc, n := range all {
+		tf := map[string]float64{}
+		pos := map[string][]int{}
+		lineOf := map[int]int{}
+		add := func(term string, p int, weight float64) {
+			tf[term] += weight
+			pos[term] = append(pos[term], p)
+			ix.docLen[doc] += weight
+		}
+
+		p := 0
+		for _, name := range notes.Names(n) {
+			// Aliases weigh as much as the title
+			for _, t := range Tokenize(name) {
+				add(t, p, titleWeight)
+				p++
+			}
+			p++ // names are separate phrases
+		}
+		p += fieldGap
+		for _, tag := range n.Tags {
+			for _, t := range Tokenize(tag) {
+				add(t, p, tagWeight)
+				p++
+			}
+			p++ // tags are separate phrases
+		}
+		p += fieldGap
+
+		offset := bodyOffset(n)
+		ix.offset[doc] = offset
+		ix.lines[doc] = strings.Split(n.Body, "\n")
+		for i, line := range ix.lines[doc] {
+			for _, t := range Tokenize(line) {
+				add(t, p, bodyWeight)
+				lineOf[p] = offset + i
+				p++
+			}
+		}
+		ix.lineOf[doc] = lineOf
+
+		for term, f := range tf {
+			ix.postings[term] = append(ix.postings[term], posting{doc: doc, tf: f, positions: pos[term]})
+		}
+		total += ix.docLen[doc]
+	}
+	if len(all) > 0 {
+		ix.avgLen = total / float64(len(all))
+	}
+	return ix
+}
+
+// Len reports how many notes are indexed.
+func (ix *Index) Len() int {
+	return len(ix.notes)
+}
+
+// Search parses query (see Query for the syntax) and runs it. A limit of 0
+// or less returns every match.
+func (ix *Index) Search(query string, limit int) ([]Result, error) {
+	q, err := Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	return ix.Run(q, limit), nil
+}
+
+// Find returns the notes matching query, best first.
+func (ix *Index) Find(query string) ([]*notes.Note, error) {
+	rs, err := ix.Search(query, 0)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*notes.Note, len(rs))
+	for i, r := range rs {
+		out[i] = r.Note
+	}
+	return out, nil
+}
+
+// Find indexes all and returns the notes matching query, best first. Use
+// Build and Index.Find instead when running several queries.
+func Find(all []*notes.Note, query string) ([]*notes.Note, error) {
+	return Build(all).Find(query)
+}
+
+// Run evaluates a parsed query. Matches are ordered by text score, then by
+// most recently updated. An empty query returns all notes in their
+// original order.
+func (ix *Index) Run(q Query, limit int) []Result {
+	if q.root == nil {
+		out := make([]Result, len(ix.notes))
+		for i, n := range ix.notes {
+			out[i] = Result{Note: n}
+		}
+		return truncateResults(out, limit)
+	}
+
+	matched := q.root.eval(ix)
+	out := make([]Result, 0, len(matched))
+	for doc, m := range matched {
+		out = append(out, Result{
+			Note:     ix.notes[doc],
+			Score:    m.score,
+			Snippets: ix.snippets(doc, m.positions),
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		if !out[i].Note.Updated.Equal(out[j].Note.Updated) {
+			return out[i].Note.Updated.After(out[j].Note.Updated)
+		}
+		return out[i].Note.ID < out[j].Note.ID
+	})
+	return truncateResults(out, limit)
+}
+
+type match struct {
+	score     float64
+	positions []int
+}
+
+// matchClause returns the notes matching c with their BM25 contribution.
+func (ix *Index) matchClause(c clause) map[int]match {
+	out := map[int]match{}
+	switch {
+	case len(c.terms) > 1:
+		docs := ix.phrase(c.terms)
+		for doc, ps := range docs {
+			// Score a phrase like one rare term occurring len(ps) times,
+			// weighted by its length so phrases outrank loose words.
+			tf := float64(len(ps))
+			out[doc] = match{score: ix.bm25(tf, doc, len(docs)) * float64(len(c.terms)), positions: ps}
+		}
+	case c.prefix:
+		for term, plist := range ix.postings {
+			if !strings.HasPrefix(term, c.terms[0]) {
+				continue
+			}
+			for _, p := range plist {
+				m := out[p.doc]
+				m.score += ix.bm25(p.tf, p.doc, len(plist))
+				m.positions = append(m.positions, p.positions...)
+				out[p.doc] = m
+			}
+		}
+	default:
+		plist := ix.postings[c.terms[0]]
+		for _, p := range plist {
+			out[p.doc] = match{score: ix.bm25(p.tf, p.doc, len(plist)), positions: p.positions}
+		}
+	}
+	return out
+}
+
+// phrase returns, per note, the start positions where terms occur in order.
+func (ix *Index) phrase(terms []string) map[int][]int {
+	first := ix.postings[terms[0]]
+	out := map[int][]int{}
+	for _, p := range first {
+		for _, start := range p.positions {
+			if ix.phraseAt(p.doc, terms, start) {
+				out[p.doc] = append(out[p.doc], start)
+			}
+		}
+	}
+	return out
+}
+
+func (ix *Index) phraseAt(doc int, terms []string, start int) bool {
+	for k := 1; k < len(terms); k++ {
+		if !ix.hasPosition(terms[k], doc, start+k) {
+			return false
+		}
+	}
+	return true
+}
+
+func (ix *Index) hasPosition(term string, doc, pos int) bool {
+	plist := ix.postings[term]
+	i := sort.Search(len(plist), func(i int) bool { return plist[i].doc >= doc })
+	if i == len(plist) || plist[i].doc != doc {
+		return false
+	}
+	ps := plist[i].positions
+	j := sort.SearchInts(ps, pos)
+	return j < len(ps) && ps[j] == pos
+}
+
+// bm25 scores a term with weighted frequency tf in doc, where df notes
+// contain the term.
+func (ix *Index) bm25(tf float64, doc, df int) float64 {
+	n := float64(len(ix.notes))
+	idf := math.Log(1 + (n-float64(df)+0.5)/(float64(df)+0.5))
+	norm := 1 - bm25B
+	if ix.avgLen > 0 {
+		norm += bm25B * ix.docLen[doc] / ix.avgLen
+	}
+	return idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+}
+
+// snippets returns up to maxSnippets body lines containing positions.
+func (ix *Index) snippets(doc int, positions []int) []Snippet {
+	seen := map[int]bool{}
+	var lines []int
+	for _, p := range positions {
+		line, ok := ix.lineOf[doc][p]
+		if !ok || seen[line] {
+			continue
+		}
+		seen[line] = true
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	if len(lines) > maxSnippets {
+		lines = lines[:maxSnippets]
+	}
+	out := make([]Snippet, 0, len(lines))
+	for _, l := range lines {
+		out = append(out, Snippet{
+			Line: l,
+			Text: strings.TrimSpace(ix.lines[doc][l-ix.offset[doc]]),
+		})
+	}
+	return out
+}
+
+// bodyOffset returns the 1-based file line number of the first body line.
+func bodyOffset(n *notes.Note) int {
+	if n.Raw == "" || !strings.HasSuffix(n.Raw, n.Body) {
+		return 1
+	}
+	return strings.Count(n.Raw[:len(n.Raw)-len(n.Body)], "\n") + 1
+}
+
+func truncateResults(rs []Result, limit int) []Result {
+	if limit > 0 && len(rs) > limit {
+		return rs[:limit]
+	}
+	return rs
+}
+
+// Tokenize lowercases s and splits it into words of letters and digits.
+func Tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,104 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/yash-srivastava19/grove/internal/notes"
+)
+
+func testNotes() []*notes.Note {
+	raw := func(id, title, body string) *notes.Note {
+		return notes.NoteFromRaw(id, "/vault/"+id+".md", "---\ntitle: "+title+"\n---\n\n"+body, testTime)
+	}
+	return []*notes.Note{
+		raw("roadmap", "Roadmap", "Q3 goals.\nShip the search index.\nThen the graph view."),
+		raw("kickoff", "Project Atlas kickoff", "Attendees: Ada, Grace.\nWe agreed the roadmap is too long."),
+		raw("cooking", "Cooking", "Search for the perfect index card recipe box.\nIndexing recipes is fun."),
+		raw("daily", "Daily 2024-01-01", "- met with Ada about Atlas\n- atlas atlas atlas"),
+	}
+}
+
+func ids(rs []Result) []string {
+	out := make([]string, len(rs))
+	for i, r := range rs {
+		out[i] = r.Note.ID
+	}
+	return out
+}
+
+func TestSearch_ranksTitleMatchesFirst(t *testing.T) {
+	ix := Build(testNotes())
+	rs, _ := ix.Search("roadmap", 0)
+	if len(rs) != 2 {
+		t.Fatalf("expected 2 results, got %v", ids(rs))
+	}
+	if rs[0].Note.ID != "roadmap" {
+		t.Errorf("title match should rank first, got %v", ids(rs))
+	}
+}
+
+func TestSearch_allTermsRequired(t *testing.T) {
+	ix := Build(testNotes())
+	rs, _ := ix.Search("ada atlas", 0)
+	got := ids(rs)
+	if len(got) != 2 {
+		t.Fatalf("expected kickoff and daily, got %v", got)
+	}
+	rs, _ = ix.Search("ada recipes", 0)
+	if len(rs) != 0 {
+		t.Errorf("expected no results, got %v", ids(rs))
+	}
+}
+
+func TestSearch_phrase(t *testing.T) {
+	ix := Build(testNotes())
+	rs, _ := ix.Search(`"search index"`, 0)
+	if len(rs) != 1 || rs[0].Note.ID != "roadmap" {
+		t.Errorf("phrase should only match roadmap, got %v", ids(rs))
+	}
+	// Both words appear in cooking, but not adjacent.
+	rs, _ = ix.Search("search index", 0)
+	if len(rs) != 2 {
+		t.Errorf("loose terms should match 2 notes, got %v", ids(rs))
+	}
+}
+
+func TestSearch_prefix(t *testing.T) {
+	ix := Build(testNotes())
+	rs, _ := ix.Search("index*", 0)
+	got := map[string]bool{}
+	for _, id := range ids(rs) {
+		got[id] = true
+	}
+	if !got["roadmap"] || !got["cooking"] || len(got) != 2 {
+		t.Errorf("prefix index* should match roadmap and cooking, got %v", ids(rs))
+	}
+}
+
+func TestSearch_snippetsHaveFileLineNumbers(t *testing.T) {
+	ix := Build(testNotes())
+	rs, _ := ix.Search("graph", 0)
+	if len(rs) != 1 || len(rs[0].Snippets) != 1 {
+		t.Fatalf("expected one snippet, got %+v", rs)
+	}
+	// Frontmatter is 3 lines, then a blank line; the body starts on line 5.
+	sn := rs[0].Snippets[0]
+	if sn.Line != 7 || sn.Text != "Then the graph view." {
+		t.Errorf("snippet: got %+v", sn)
+	}
+}
+
+func TestSearch_emptyQueryReturnsAll(t *testing.T) {
+	all := testNotes()
+	rs, _ := Build(all).Search("  ", 0)
+	if len(rs) != len(all) {
+		t.Errorf("expected all %d notes, got %d", len(all), len(rs))
+	}
+}
+
+func TestSearch_limit(t *testing.T) {
+	rs, _ := Build(testNotes()).Search("atlas", 1)
+	if len(rs) != 1 || rs[0].Note.ID != "daily" {
+		t.Errorf("expected only the top hit (daily), got %v", ids(rs))
+	}
+}
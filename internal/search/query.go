@@ -0,0 +1,482 @@
+package search
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yash-srivastava19/grove/internal/notes"
+)
+
+// Query is a parsed search query. The syntax is:
+//
+//	word "exact phrase" pref*     text, ranked with BM25
+//	tag:work  -tag:archive        has the tag (or a nested tag/below it)
+//	title:"kickoff"               title contains the text
+//	folder:projects               note is in the folder or below it
+//	links:Roadmap                 note wiki-links to Roadmap
+//	has:todo  has:link  has:tag   open "- [ ]" items / any links / any tags
+//	created:>2026-09-01           also >=, <, <=; plain means that day
+//	updated:>=7d                  relative: Nd, Nw, today, yesterday
+//	a OR b   a AND b   NOT a  -a  (grouping)
+//
+// Terms next to each other must all match. AND, OR and NOT are only
+// operators in upper case.
+type Query struct {
+	root node // nil matches every note
+}
+
+// ExpandSaved replaces each @name word in query with the saved search it
+// names, wrapped in parentheses so it combines with the rest of the query.
+// lookup returns the saved query for a name.
+func ExpandSaved(query string, lookup func(name string) (string, bool)) (string, error) {
+	toks := lex(query)
+	for i, tok := range toks {
+		if len(tok) < 2 || tok[0] != '@' {
+			continue
+		}
+		saved, ok := lookup(tok[1:])
+		if !ok {
+			return "", fmt.Errorf("no saved search named %s", tok)
+		}
+		// Saved searches don't nest, which keeps expansion from looping.
+		for _, t := range lex(saved) {
+			if len(t) > 1 && t[0] == '@' {
+				return "", fmt.Errorf("saved search %s refers to another saved search", tok)
+			}
+		}
+		toks[i] = "(" + saved + ")"
+	}
+	return strings.Join(toks, " "), nil
+}
+
+// now is replaced in tests so relative dates are deterministic.
+var now = time.Now
+
+// Parse parses a query string.
+func Parse(q string) (Query, error) {
+	p := &parser{toks: lex(q)}
+	if len(p.toks) == 0 {
+		return Query{}, nil
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return Query{}, err
+	}
+	if root == nil {
+		return Query{}, nil
+	}
+	if p.pos < len(p.toks) {
+		return Query{}, fmt.Errorf("unexpected %q", p.toks[p.pos])
+	}
+	return Query{root: root}, nil
+}
+
+// node is one element of a parsed query. eval returns the notes it matches
+// with their text score.
+type node interface {
+	eval(ix *Index) map[int]match
+}
+
+type textNode struct{ c clause }
+
+type filterNode struct {
+	match func(n *notes.Note) bool
+}
+
+// linksNode matches notes linking to target. Links resolve through titles
+// and aliases, so links:K8s also finds [[Kubernetes]].
+type linksNode struct{ target string }
+
+type andNode struct{ kids []node }
+type orNode struct{ kids []node }
+type notNode struct{ kid node }
+
+func (t textNode) eval(ix *Index) map[int]match { return ix.matchClause(t.c) }
+
+func (f filterNode) eval(ix *Index) map[int]match {
+	out := map[int]match{}
+	for doc, n := range ix.notes {
+		if f.match(n) {
+			out[doc] = match{}
+		}
+	}
+	return out
+}
+
+func (l linksNode) eval(ix *Index) map[int]match {
+	want := ix.resolver.Resolve(l.target)
+	out := map[int]match{}
+	for doc, n := range ix.notes {
+		for _, link := range n.Links {
+			if strings.EqualFold(link.Target, l.target) || (want != nil && ix.resolver.Resolve(link.Target) == want) {
+				out[doc] = match{}
+				break
+			}
+		}
+	}
+	return out
+}
+
+func (a andNode) eval(ix *Index) map[int]match {
+	out := a.kids[0].eval(ix)
+	for _, k := range a.kids[1:] {
+		if len(out) == 0 {
+			break
+		}
+		next := k.eval(ix)
+		for doc, m := range out {
+			km, ok := next[doc]
+			if !ok {
+				delete(out, doc)
+				continue
+			}
+			out[doc] = match{score: m.score + km.score, positions: append(m.positions, km.positions...)}
+		}
+	}
+	return out
+}
+
+func (o orNode) eval(ix *Index) map[int]match {
+	out := map[int]match{}
+	for _, k := range o.kids {
+		for doc, km := range k.eval(ix) {
+			m := out[doc]
+			out[doc] = match{score: m.score + km.score, positions: append(m.positions, km.positions...)}
+		}
+	}
+	return out
+}
+
+func (n notNode) eval(ix *Index) map[int]match {
+	excluded := n.kid.eval(ix)
+	out := map[int]match{}
+	for doc := range ix.notes {
+		if _, ok := excluded[doc]; !ok {
+			out[doc] = match{}
+		}
+	}
+	return out
+}
+
+// ── Parser ────────────────────────────────────────────────────────────────────
+
+type parser struct {
+	toks []string
+	pos  int
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) parseOr() (node, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	var kids []node
+	if first != nil {
+		kids = append(kids, first)
+	}
+	for p.peek() == "OR" {
+		p.pos++
+		next, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if next != nil {
+			kids = append(kids, next)
+		}
+	}
+	switch len(kids) {
+	case 0:
+		return nil, nil
+	case 1:
+		return kids[0], nil
+	}
+	return orNode{kids: kids}, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	var kids []node
+	for {
+		switch p.peek() {
+		case "", ")", "OR":
+			switch len(kids) {
+			case 0:
+				return nil, nil
+			case 1:
+				return kids[0], nil
+			}
+			return andNode{kids: kids}, nil
+		case "AND":
+			p.pos++
+			continue
+		}
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if n != nil {
+			kids = append(kids, n)
+		}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	tok := p.peek()
+	if tok == "NOT" || tok == "-" {
+		p.pos++
+		kid, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if kid == nil {
+			return nil, fmt.Errorf("%s needs a term after it", tok)
+		}
+		return notNode{kid: kid}, nil
+	}
+	if len(tok) > 1 && tok[0] == '-' {
+		p.toks[p.pos] = tok[1:]
+		kid, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if kid == nil {
+			return nil, nil
+		}
+		return notNode{kid: kid}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.peek()
+	p.pos++
+	if tok == "(" {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if inner == nil {
+			return nil, fmt.Errorf("empty ()")
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return inner, nil
+	}
+
+	if i := strings.Index(tok, ":"); i > 0 && !strings.HasPrefix(tok, `"`) {
+		field := strings.ToLower(tok[:i])
+		value := strings.Trim(tok[i+1:], `"`)
+		if field == "links" {
+			if value == "" {
+				return nil, fmt.Errorf("links: needs a value")
+			}
+			return linksNode{target: value}, nil
+		}
+		if f, ok := filters[field]; ok {
+			if value == "" {
+				return nil, fmt.Errorf("%s: needs a value", field)
+			}
+			match, err := f(value)
+			if err != nil {
+				return nil, err
+			}
+			return filterNode{match: match}, nil
+		}
+	}
+
+	// Plain text; words with no letters or digits (like a lone *) are
+	// dropped rather than matching nothing.
+	cs := parseText(tok)
+	if len(cs) == 0 {
+		return nil, nil
+	}
+	return textNode{c: cs[0]}, nil
+}
+
+// ── Filters ───────────────────────────────────────────────────────────────────
+
+var filters = map[string]func(value string) (func(*notes.Note) bool, error){
+	"tag": func(v string) (func(*notes.Note) bool, error) {
+		v = strings.ToLower(strings.TrimPrefix(v, "#"))
+		return func(n *notes.Note) bool {
+			for _, t := range n.Tags {
+				t = strings.ToLower(t)
+				if t == v || strings.HasPrefix(t, v+"/") {
+					return true
+				}
+			}
+			return false
+		}, nil
+	},
+	"title": func(v string) (func(*notes.Note) bool, error) {
+		v = strings.ToLower(v)
+		return func(n *notes.Note) bool {
+			for _, name := range notes.Names(n) {
+				if strings.Contains(strings.ToLower(name), v) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	},
+	"folder": func(v string) (func(*notes.Note) bool, error) {
+		v = strings.Trim(v, "/")
+		return func(n *notes.Note) bool {
+			return n.Folder == v || strings.HasPrefix(n.Folder, v+"/")
+		}, nil
+	},
+	"has": func(v string) (func(*notes.Note) bool, error) {
+		switch strings.ToLower(v) {
+		case "todo":
+			return func(n *notes.Note) bool { return hasOpenTodo(n.Body) }, nil
+		case "link", "links":
+			return func(n *notes.Note) bool { return len(n.Links) > 0 }, nil
+		case "tag", "tags":
+			return func(n *notes.Note) bool { return len(n.Tags) > 0 }, nil
+		}
+		return nil, fmt.Errorf("has:%s — expected has:todo, has:link or has:tag", v)
+	},
+	"created": func(v string) (func(*notes.Note) bool, error) {
+		return dateFilter("created", v, func(n *notes.Note) time.Time { return n.Created })
+	},
+	"updated": func(v string) (func(*notes.Note) bool, error) {
+		return dateFilter("updated", v, func(n *notes.Note) time.Time { return n.Updated })
+	},
+}
+
+func hasOpenTodo(body string) bool {
+	for _, line := range strings.Split(body, "\n") {
+		l := strings.TrimSpace(line)
+		if strings.HasPrefix(l, "- [ ]") || strings.HasPrefix(l, "* [ ]") {
+			return true
+		}
+	}
+	return false
+}
+
+// dateFilter parses values like ">2026-09-01", "<=7d" or "today". Without
+// an operator the note must fall on that day.
+func dateFilter(field, v string, get func(*notes.Note) time.Time) (func(*notes.Note) bool, error) {
+	op := ""
+	for _, o := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(v, o) {
+			op, v = o, strings.TrimPrefix(v, o)
+			break
+		}
+	}
+	day, err := parseDay(v)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%s — %v", field, v, err)
+	}
+	next := day.AddDate(0, 0, 1)
+	return func(n *notes.Note) bool {
+		t := get(n).In(day.Location())
+		switch op {
+		case ">":
+			return !t.Before(next)
+		case ">=":
+			return !t.Before(day)
+		case "<":
+			return t.Before(day)
+		case "<=":
+			return t.Before(next)
+		}
+		return !t.Before(day) && t.Before(next)
+	}, nil
+}
+
+// parseDay returns local midnight of the day v names.
+func parseDay(v string) (time.Time, error) {
+	t := now()
+	today := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch strings.ToLower(v) {
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+	if len(v) > 1 {
+		unit := v[len(v)-1]
+		if k, err := strconv.Atoi(v[:len(v)-1]); err == nil && (unit == 'd' || unit == 'w') {
+			if unit == 'w' {
+				k *= 7
+			}
+			return today.AddDate(0, 0, -k), nil
+		}
+	}
+	d, err := time.ParseInLocation("2006-01-02", v, t.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected YYYY-MM-DD, Nd, Nw, today or yesterday")
+	}
+	return d, nil
+}
+
+// ── Text ──────────────────────────────────────────────────────────────────────
+
+// clause is one required part of a query: a single term, a prefix, or a
+// phrase of several terms that must appear in order.
+type clause struct {
+	terms  []string
+	prefix bool // match any term starting with terms[0]
+}
+
+// parseText splits a query into clauses. "Quoted text" becomes a phrase;
+// a word ending in * matches by prefix. Words that tokenize into several
+// terms (like "follow-up") are treated as phrases.
+func parseText(query string) []clause {
+	var out []clause
+	for _, word := range lex(query) {
+		quoted := strings.HasPrefix(word, `"`)
+		word = strings.Trim(word, `"`)
+		prefix := !quoted && strings.HasSuffix(word, "*")
+		terms := Tokenize(word)
+		if len(terms) == 0 {
+			continue
+		}
+		out = append(out, clause{terms: terms, prefix: prefix && len(terms) == 1})
+	}
+	return out
+}
+
+// lex splits a query on whitespace, keeping "quoted phrases" (including
+// ones after a field, like title:"a b") together with their quotes.
+// Parentheses outside quotes are separate tokens.
+func lex(q string) []string {
+	var out []string
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			out = append(out, cur.String())
+			cur.Reset()
+		}
+	}
+	inQuote := false
+	for _, r := range q {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			cur.WriteRune(r)
+		case inQuote:
+			cur.WriteRune(r)
+		case r == ' ' || r == '\t' || r == '\n':
+			flush()
+		case r == '(' || r == ')':
+			flush()
+			out = append(out, string(r))
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return out
+}
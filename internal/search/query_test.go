@@ -0,0 +1,198 @@
+package search
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/yash-srivastava19/grove/internal/notes"
+)
+
+var testTime = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func TestParseText(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []clause
+	}{
+		{"hello", []clause{{terms: []string{"hello"}}}},
+		{"Hello World", []clause{{terms: []string{"hello"}}, {terms: []string{"world"}}}},
+		{`"project atlas" kick*`, []clause{{terms: []string{"project", "atlas"}}, {terms: []string{"kick"}, prefix: true}}},
+		{"follow-up", []clause{{terms: []string{"follow", "up"}}}},
+		{`"" *`, nil},
+	}
+	for _, tt := range tests {
+		got := parseText(tt.query)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseText(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func queryNotes() []*notes.Note {
+	mk := func(id, fm, body string) *notes.Note {
+		return notes.NoteFromRaw(id, "/vault/"+id+".md", "---\n"+fm+"\n---\n\n"+body, testTime)
+	}
+	return []*notes.Note{
+		mk("standup", "title: Standup\ntags: [work, meeting]\nupdated: 2024-05-30T09:00:00Z", "- [ ] send notes\nSee [[Roadmap]]."),
+		mk("kickoff", "title: Atlas Kickoff\ntags: [work/atlas]\nupdated: 2024-05-01T09:00:00Z", "- [x] booked room\nLinks to [[roadmap]]."),
+		mk("old", "title: Old plans\ntags: [work, archive]\nupdated: 2023-01-01T09:00:00Z", "Archived roadmap thoughts."),
+		mk("recipes", "title: Recipes\ntags: []\nupdated: 2024-05-31T09:00:00Z", "Nothing about work here."),
+	}
+}
+
+func findIDs(t *testing.T, query string) []string {
+	t.Helper()
+	rs, err := Build(queryNotes()).Search(query, 0)
+	if err != nil {
+		t.Fatalf("Search(%q): %v", query, err)
+	}
+	got := ids(rs)
+	sort.Strings(got)
+	return got
+}
+
+func TestQuery_filters(t *testing.T) {
+	orig := now
+	now = func() time.Time { return time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC) }
+	defer func() { now = orig }()
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"tag:work", []string{"kickoff", "old", "standup"}},
+		{"tag:work -tag:archive", []string{"kickoff", "standup"}},
+		{"tag:work NOT tag:archive", []string{"kickoff", "standup"}},
+		{`title:"atlas kick"`, []string{"kickoff"}},
+		{"links:Roadmap", []string{"kickoff", "standup"}},
+		{"has:todo", []string{"standup"}},
+		{"has:link -has:todo", []string{"kickoff"}},
+		{"has:tag", []string{"kickoff", "old", "standup"}},
+		{"updated:>2024-05-01", []string{"recipes", "standup"}},
+		{"updated:>=2024-05-01", []string{"kickoff", "recipes", "standup"}},
+		{"updated:<2024-01-01", []string{"old"}},
+		{"updated:2024-05-30", []string{"standup"}},
+		{"updated:>=7d", []string{"recipes", "standup"}},
+		{"roadmap tag:archive", []string{"old"}},
+		{"tag:meeting OR tag:archive", []string{"old", "standup"}},
+		{"(tag:meeting OR recipes) -has:todo", []string{"recipes"}},
+		{"tag:work AND roadmap", []string{"kickoff", "old", "standup"}},
+		{"http://example.com", nil},
+	}
+	for _, tt := range tests {
+		got := findIDs(t, tt.query)
+		if !reflect.DeepEqual(got, tt.want) && !(len(got) == 0 && len(tt.want) == 0) {
+			t.Errorf("%s: got %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestQuery_aliases(t *testing.T) {
+	mk := func(id, fm, body string) *notes.Note {
+		return notes.NoteFromRaw(id, "/vault/"+id+".md", "---\n"+fm+"\n---\n\n"+body, testTime)
+	}
+	ix := Build([]*notes.Note{
+		mk("k8s", "title: Kubernetes\naliases: [K8s, kube]", "Cluster notes."),
+		mk("deploy", "title: Deploy", "Runs on [[K8s]]."),
+		mk("ops", "title: Ops", "See [[Kubernetes]]."),
+	})
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"kube", []string{"k8s"}},
+		{"title:kube", []string{"k8s"}},
+		{"links:Kubernetes", []string{"deploy", "ops"}},
+		{"links:kube", []string{"deploy", "ops"}},
+	}
+	for _, tt := range tests {
+		rs, err := ix.Search(tt.query, 0)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.query, err)
+		}
+		got := ids(rs)
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestQuery_textRanksAmongFiltered(t *testing.T) {
+	rs, err := Build(queryNotes()).Search("tag:work roadmap", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range rs {
+		if r.Score <= 0 {
+			t.Errorf("%s: text matches should carry a score", r.Note.ID)
+		}
+	}
+}
+
+func TestQuery_errors(t *testing.T) {
+	for _, q := range []string{"has:pizza", "updated:>soon", "(tag:work", "tag:", "NOT", "()"} {
+		if _, err := Parse(q); err == nil {
+			t.Errorf("Parse(%q): expected an error", q)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	got, err := Find(queryNotes(), "tag:meeting")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != "standup" {
+		t.Errorf("Find: got %v", got)
+	}
+}
+
+func TestExpandSaved(t *testing.T) {
+	saved := map[string]string{"todo": "has:todo -tag:archive", "loop": "@todo"}
+	lookup := func(name string) (string, bool) {
+		q, ok := saved[name]
+		return q, ok
+	}
+
+	got, err := ExpandSaved("@todo tag:work", lookup)
+	if err != nil || got != "(has:todo -tag:archive) tag:work" {
+		t.Errorf("ExpandSaved: got %q, %v", got, err)
+	}
+	if _, err := ExpandSaved("@missing", lookup); err == nil {
+		t.Error("expected error for unknown saved search")
+	}
+	if _, err := ExpandSaved("@loop", lookup); err == nil {
+		t.Error("expected error for nested saved search")
+	}
+}
+
+func TestExpandSaved_savedFromSaved(t *testing.T) {
+	saved := map[string]string{"work": "tag:work -tag:archive"}
+	lookup := func(name string) (string, bool) {
+		q, ok := saved[name]
+		return q, ok
+	}
+
+	// `grove search --save todo @work has:todo` saves the expansion, so
+	// @todo doesn't refer to another saved search.
+	expanded, err := ExpandSaved("@work has:todo", lookup)
+	if err != nil {
+		t.Fatalf("ExpandSaved: %v", err)
+	}
+	saved["todo"] = expanded
+
+	q, err := ExpandSaved("@todo", lookup)
+	if err != nil {
+		t.Fatalf("ExpandSaved(@todo): %v", err)
+	}
+	rs, err := Build(queryNotes()).Search(q, 0)
+	if err != nil {
+		t.Fatalf("Search(%q): %v", q, err)
+	}
+	if got := ids(rs); !reflect.DeepEqual(got, []string{"standup"}) {
+		t.Errorf("@todo: got %v", got)
+	}
+}
@@ -0,0 +1,160 @@
+package search
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yash-srivastava19/grove/internal/notes"
+)
+
+// Passage is a section of a note: the text under one heading, or before
+// the first. It is the unit Retrieve ranks.
+type Passage struct {
+	Note    *notes.Note
+	Heading string // "" for text before the first heading
+	Text    string // the section, heading line included
+	Score   float64
+}
+
+// Tokens estimates how many model tokens p's text takes, at about four
+// characters a token.
+func (p Passage) Tokens() int {
+	return (len(p.Text) + 3) / 4
+}
+
+// Chunk splits n's body into passages at its headings. Headings inside
+// fenced code don't split, and sections with no text are dropped.
+func Chunk(n *notes.Note) []Passage {
+	var out []Passage
+	heading := ""
+	var cur []string
+	flush := func() {
+		content := cur
+		if heading != "" && len(content) > 0 {
+			content = content[1:] // the heading line itself
+		}
+		if strings.TrimSpace(strings.Join(content, "\n")) != "" {
+			out = append(out, Passage{Note: n, Heading: heading, Text: strings.TrimSpace(strings.Join(cur, "\n"))})
+		}
+		cur = nil
+	}
+	fenced := false
+	for _, line := range strings.Split(n.Body, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fenced = !fenced
+		}
+		if h, ok := headingText(line); ok && !fenced {
+			flush()
+			heading = h
+		}
+		cur = append(cur, line)
+	}
+	flush()
+	return out
+}
+
+// headingText returns the text of a markdown heading line.
+func headingText(line string) (string, bool) {
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	if level == 0 || level > 6 || len(line) == level || line[level] != ' ' {
+		return "", false
+	}
+	return strings.TrimSpace(line[level:]), true
+}
+
+// Retrieve finds the passages in all that best answer question: it chunks
+// every note by heading, ranks the chunks against the question's words
+// with BM25 (title, aliases and heading weigh most), and returns the best
+// ones, at most k, that fit in maxTokens together. A passage that would
+// overflow the budget is cut to fit if it's the first, and skipped
+// otherwise. When no words of the question match anything, the passages
+// of the most recently updated notes are used instead.
+func Retrieve(all []*notes.Note, question string, k, maxTokens int) []Passage {
+	var passages []Passage
+	var docs []*notes.Note
+	for _, n := range all {
+		for _, p := range Chunk(n) {
+			aliases := append([]string(nil), n.Aliases...)
+			if p.Heading != "" {
+				aliases = append(aliases, p.Heading)
+			}
+			docs = append(docs, &notes.Note{
+				ID:      strconv.Itoa(len(passages)),
+				Title:   n.Title,
+				Aliases: aliases,
+				Tags:    n.Tags,
+				Body:    p.Text,
+				Updated: n.Updated,
+			})
+			passages = append(passages, p)
+		}
+	}
+
+	var ranked []Passage
+	if q := questionQuery(question); q != "" {
+		rs, err := Build(docs).Search(q, 0)
+		if err == nil {
+			for _, r := range rs {
+				i, _ := strconv.Atoi(r.Note.ID)
+				p := passages[i]
+				p.Score = r.Score
+				ranked = append(ranked, p)
+			}
+		}
+	}
+	if len(ranked) == 0 {
+		ranked = append(ranked, passages...)
+		sort.SliceStable(ranked, func(i, j int) bool {
+			return ranked[i].Note.Updated.After(ranked[j].Note.Updated)
+		})
+	}
+
+	var out []Passage
+	budget := maxTokens
+	for _, p := range ranked {
+		if len(out) == k {
+			break
+		}
+		if p.Tokens() > budget {
+			if len(out) > 0 {
+				continue
+			}
+			cut := budget * 4
+			for cut > 0 && !utf8.RuneStart(p.Text[cut]) {
+				cut--
+			}
+			p.Text = p.Text[:cut] + "…"
+		}
+		budget -= p.Tokens()
+		out = append(out, p)
+	}
+	return out
+}
+
+// questionQuery turns a natural-language question into a query matching
+// any of its words, leaving out words too common to help.
+func questionQuery(question string) string {
+	seen := map[string]bool{}
+	var terms []string
+	for _, t := range Tokenize(question) {
+		if stopWords[t] || seen[t] {
+			continue
+		}
+		seen[t] = true
+		terms = append(terms, t)
+	}
+	return strings.Join(terms, " OR ")
+}
+
+var stopWords = func() map[string]bool {
+	m := map[string]bool{}
+	for _, w := range strings.Fields(`a about all an and any are as at be been but by can could
+		did do does for from had has have how i if in into is it its me my of on or our
+		she should so than that the their them then there these they this to was we were
+		what when where which who why will with would you your`) {
+		m[w] = true
+	}
+	return m
+}()
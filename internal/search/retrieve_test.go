@@ -0,0 +1,54 @@
+package search
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yash-srivastava19/grove/internal/notes"
+)
+
+func TestChunk(t *testing.T) {
+	n := &notes.Note{ID: "n", Body: "Intro text.\n\n# Setup\n\nInstall it.\n\n```sh\n# not a heading\n```\n\n## Empty\n\n## Usage\nRun it."}
+	got := Chunk(n)
+	want := []struct{ heading, text string }{
+		{"", "Intro text."},
+		{"Setup", "# Setup\n\nInstall it.\n\n```sh\n# not a heading\n```"},
+		{"Usage", "## Usage\nRun it."},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d passages: %+v", len(got), got)
+	}
+	for i, w := range want {
+		if got[i].Heading != w.heading || got[i].Text != w.text {
+			t.Errorf("passage %d = %q %q, want %q %q", i, got[i].Heading, got[i].Text, w.heading, w.text)
+		}
+	}
+}
+
+func TestRetrieve(t *testing.T) {
+	atlas := &notes.Note{ID: "atlas", Title: "Project Atlas", Body: "Overview.\n\n## Budget\n\nWe have 40k for the pilot.\n\n## Team\n\nAda and Grace."}
+	cooking := &notes.Note{ID: "cooking", Title: "Cooking", Body: "Budget recipes for students.\n\n## Soup\n\nLentils."}
+	old := &notes.Note{ID: "old", Title: "Old", Body: "Nothing relevant.", Updated: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	all := []*notes.Note{atlas, cooking, old}
+
+	got := Retrieve(all, "What is the budget for Atlas?", 2, 1000)
+	if len(got) != 2 || got[0].Note != atlas || got[0].Heading != "Budget" {
+		t.Fatalf("got %+v", got)
+	}
+	if got[0].Score <= got[1].Score {
+		t.Errorf("scores not descending: %v, %v", got[0].Score, got[1].Score)
+	}
+
+	// The token budget keeps later passages out, and cuts the first to fit
+	if got := Retrieve(all, "budget atlas", 5, 8); len(got) != 1 || got[0].Tokens() > 9 || !strings.HasSuffix(got[0].Text, "…") {
+		t.Errorf("budget: %+v", got)
+	}
+
+	// Nothing matches: fall back to recent notes
+	atlas.Updated = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	got = Retrieve(all, "what is it?", 1, 1000)
+	if len(got) != 1 || got[0].Note != atlas {
+		t.Errorf("fallback: %+v", got)
+	}
+}
@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yash-srivastava19/grove/internal/notes"
+)
+
+// aiHeading is the section answers are appended under.
+const aiHeading = "AI"
+
+// maxAnswerTitle is how much of a question becomes the title of a note
+// made from its answer.
+const maxAnswerTitle = 60
+
+// Where ctrl+s in an AI panel saves the last answer.
+const (
+	saveAppend = 'a' // the note the panel is about
+	saveNew    = 'n' // a new note linking back to its sources
+	saveDaily  = 't' // today's daily note
+)
+
+// lastAnswer returns the newest answered entry in history, or nil.
+func lastAnswer(history []aiEntry) *aiEntry {
+	for i := len(history) - 1; i >= 0; i-- {
+		if strings.TrimSpace(history[i].answer) != "" {
+			return &history[i]
+		}
+	}
+	return nil
+}
+
+// answerBlock formats e for appending to a note: the question as a
+// heading, the answer, then links to the notes it cites. Headings in the
+// answer are demoted to sit under the question's.
+func answerBlock(e aiEntry) string {
+	return "### " + strings.Join(strings.Fields(e.question), " ") + "\n\n" + notes.DemoteHeadings(answerText(e), 4)
+}
+
+// answerText is e's answer followed by links to its sources.
+func answerText(e aiEntry) string {
+	text := strings.TrimSpace(e.answer)
+	if len(e.sources) == 0 {
+		return text
+	}
+	text += "\n\nSources:\n"
+	for _, c := range e.sources {
+		target := c.Title
+		if c.Section != "" {
+			target += "#" + c.Section
+		}
+		text += fmt.Sprintf("- [%d] [[%s]]\n", c.N, target)
+	}
+	return strings.TrimRight(text, "\n")
+}
+
+// answerTitle makes a note title from a question, cut at a word boundary.
+func answerTitle(question string) string {
+	title := strings.Join(strings.Fields(question), " ")
+	title = strings.TrimRight(title, "?!. ")
+	if utf8.RuneCountInString(title) <= maxAnswerTitle {
+		return title
+	}
+	cut := string([]rune(title)[:maxAnswerTitle])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
+
+// saveAnswer writes e into the vault. about is the note the answer is
+// about, if any: the target for saveAppend, and linked from a new note.
+// It returns the note written to, or nil if saving failed (the status line
+// or conflict screen says why).
+func (a *App) saveAnswer(e aiEntry, target rune, about *notes.Note) *notes.Note {
+	var n *notes.Note
+	var err error
+	switch target {
+	case saveAppend:
+		if about == nil {
+			a.setStatus("no note to append to", true)
+			return nil
+		}
+		n, err = a.store.Load(about.ID)
+	case saveDaily:
+		n, err = a.store.CreateDaily()
+	case saveNew:
+		folder := ""
+		if about != nil {
+			folder = about.Folder
+		}
+		n, err = a.store.CreateIn(folder, answerTitle(e.question), []string{"ai"})
+	}
+	if err != nil {
+		a.setStatus("save error: "+err.Error(), true)
+		return nil
+	}
+
+	if target == saveNew {
+		n.Body = answerText(e) + "\n"
+		if about != nil {
+			n.Body += "\nAsked about [[" + about.Title + "]].\n"
+		}
+	} else {
+		n.Body = notes.AppendSection(n.Body, aiHeading, answerBlock(e))
+	}
+	notes.RecordAI(n, a.ai.Model(), e.question, time.Now())
+	if !a.saveNote(n) {
+		return nil
+	}
+	if a.current != nil && a.current.ID == n.ID {
+		a.current = n
+		a.reRender()
+	}
+	a.setStatus("saved answer to "+n.Title, false)
+	return n
+}
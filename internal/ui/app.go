@@ -1,9 +1,12 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -12,10 +15,11 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/sahilm/fuzzy"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/yash-srivastava19/grove/internal/ai"
 	"github.com/yash-srivastava19/grove/internal/config"
 	"github.com/yash-srivastava19/grove/internal/notes"
+	"github.com/yash-srivastava19/grove/internal/search"
 	"github.com/yash-srivastava19/grove/internal/templates"
 )
 
@@ -33,6 +37,13 @@ const (
 	stateHelp
 	stateLinks    // L key: wiki-links panel
 	stateVaultAI  // @ key: vault-wide AI
+	stateConflict // save hit a note changed on disk
+	stateRename   // R key: enter a new title
+	stateRenamePreview
+	stateDoctor     // D key: vault health report
+	stateCreateLink // followed a link to a note that doesn't exist
+	stateGraph      // M key: the open note's link neighborhood
+	stateTags       // T key: review AI tag suggestions
 )
 
 // ── Messages ──────────────────────────────────────────────────────────────────
@@ -42,29 +53,65 @@ type notesLoadedMsg struct {
 	err   error
 }
 
+type watchStartedMsg struct {
+	watcher *notes.Watcher
+	err     error
+}
+
+// vaultChangedMsg reports notes changed on disk by something other than grove.
+type vaultChangedMsg struct {
+	ids []string
+}
+
 type editorClosedMsg struct {
 	notes  []*notes.Note
 	openID string
 	err    error
 }
 
+// aiChunkMsg is part of a streamed answer in the AI panel; next waits for
+// the rest.
+type aiChunkMsg struct {
+	stream int
+	text   string
+	next   tea.Cmd
+}
+
 type aiResponseMsg struct {
+	stream   int
 	response string
 	err      error
 }
 
+type vaultAIChunkMsg struct {
+	stream int
+	text   string
+	next   tea.Cmd
+}
+
 type vaultAIResponseMsg struct {
-	response string
-	err      error
+	stream    int
+	response  string
+	citations []ai.Citation
+	err       error
+}
+
+// tagsSuggestedMsg carries the AI's tags for tagReviews[index].
+type tagsSuggestedMsg struct {
+	run         int
+	index       int
+	suggestions []ai.TagSuggestion
+	err         error
 }
 
 // ── App struct ────────────────────────────────────────────────────────────────
 
 // App is the main Bubble Tea model.
 type App struct {
-	cfg   *config.Config
-	store *notes.Store
-	ai    *ai.Client
+	cfg     *config.Config
+	store   *notes.Store
+	ai      *ai.Client
+	watcher *notes.Watcher
 
 	state  appState
 	width  int
@@ -76,6 +123,11 @@ type App struct {
 	cursor     int
 	listOffset int
 
+	// Folder tree (f key): when treeMode is on, cursor indexes rows
+	treeMode  bool
+	rows      []listRow
+	collapsed map[string]bool
+
 	// Viewer
 	current  *notes.Note
 	viewport viewport.Model
@@ -86,23 +138,71 @@ type App struct {
 	aiInput         textinput.Model
 	vaultAIInput    textinput.Model
 	templateTitleIn textinput.Model
+	renameInput     textinput.Model
 
 	// Search
-	searchQuery string
-
-	// AI (per-note)
+	searchQuery   string
+	searchIdx     *search.Index   // nil until first search after a reload
+	searchResults []search.Result // parallel to filtered while searching
+	searchErr     string          // query parse error, shown under the input
+
+	// Saved searches sidebar
+	sidebarFocus  bool
+	sidebarCursor int
+	activeSaved   string // saved search filtering the list, or ""
+	savedCounts   []int  // matches per saved search, parallel to cfg.SavedSearches
+
+	// AI (per-note). aiStream numbers requests so a cancelled one's late
+	// messages are ignored; aiCancel stops the one in flight.
 	aiHistory []aiEntry
 	aiLoading bool
 	aiError   string
+	aiStream  int
+	aiCancel  context.CancelFunc
 
 	// Vault AI
 	vaultAIHistory []aiEntry
 	vaultAILoading bool
 	vaultAIError   string
+	vaultAIStream  int
+	vaultAICancel  context.CancelFunc
+
+	// Every answered question, kept between sessions
+	aiLog *ai.Log
+
+	// Tag suggestions (T): one review per note, asked for one at a time.
+	// tagRun numbers runs so a cancelled one's late answers are ignored.
+	tagReviews []tagReview
+	tagMore    int // untagged notes beyond maxTagBatch, left for next time
+	tagVocab   []notes.TagCount
+	tagCursor  int // index into tagRows()
+	tagRun     int
+	tagCancel  context.CancelFunc
+	tagReturn  appState
+
+	// ctrl+s in either AI panel: waiting for where to save the last answer
+	aiSaving bool
 
 	// Delete
 	deleteTarget *notes.Note
 
+	// Dangling link to create a note for, and the template picked for it
+	createLink   notes.Link
+	createCursor int
+
+	// Doctor
+	problems     []notes.Problem
+	doctorCursor int
+
+	// Rename: the note, the dry-run plan, and the state to return to
+	renameTarget *notes.Note
+	renamePlan   *notes.RenamePlan
+	renameReturn appState
+
+	// Save conflict: the pending save and the state to return to
+	conflict       *notes.ConflictError
+	conflictReturn appState
+
 	// Vim g-prefix tracking
 	lastKey string
 
@@ -117,18 +217,36 @@ type App struct {
 	templateCursor   int
 	selectedTemplate string
 
+	// Folder that n/N create new notes in (set from the tree selection)
+	newNoteFolder string
+
 	// Links panel
-	linksCursor  int
-	linksOut     []string      // outgoing link targets
-	linksBack    []*notes.Note // backlinks
+	linksCursor   int
+	linksOut      []notes.Link    // outgoing links
+	linksBack     []*notes.Note   // backlinks
+	linksMentions []notes.Mention // unlinked mentions of the note elsewhere
+
+	// Link graph (M key): built on first use after a reload
+	graph       *notes.Graph
+	graphCenter *notes.Note
+	graphHops   int
+	graphRows   []graphRow
+	graphCursor int
+
+	// Wiki-link targets by title and alias, rebuilt on every reload
+	resolver     *notes.Resolver
+	aliasWarning string // last alias conflict reported, to warn only once
 
 	// Rendered lines for paragraph navigation
 	renderedLines []string
 }
 
 type aiEntry struct {
-	question string
-	answer   string
+	question  string
+	answer    string
+	cancelled bool
+	sources   []ai.Citation // notes the answer cites (vault AI)
+	asked     time.Time     // when, for answers reloaded from the AI log
 }
 
 func New(cfg *config.Config, store *notes.Store, aiClient *ai.Client) *App {
@@ -152,9 +270,13 @@ func New(cfg *config.Config, store *notes.Store, aiClient *ai.Client) *App {
 	tti.Placeholder = "note title..."
 	tti.CharLimit = 200
 
+	ri := textinput.New()
+	ri.Placeholder = "new title..."
+	ri.CharLimit = 200
+
 	vp := viewport.New(80, 20)
 
-	return &App{
+	a := &App{
 		cfg:             cfg,
 		store:           store,
 		ai:              aiClient,
@@ -163,12 +285,25 @@ func New(cfg *config.Config, store *notes.Store, aiClient *ai.Client) *App {
 		aiInput:         aip,
 		vaultAIInput:    vaip,
 		templateTitleIn: tti,
+		renameInput:     ri,
 		viewport:        vp,
+		collapsed:       map[string]bool{},
+		aiLog:           ai.OpenLog(cfg.AILogFile()),
 	}
+	a.vaultAIHistory = a.loggedEntries("")
+	return a
 }
 
 func (a *App) Init() tea.Cmd {
-	return a.cmdLoadNotes()
+	return tea.Batch(a.cmdLoadNotes(), a.cmdStartWatch())
+}
+
+// Close stops watching the vault. Call it once the program has exited.
+func (a *App) Close() error {
+	if a.watcher == nil {
+		return nil
+	}
+	return a.watcher.Close()
 }
 
 // ── Commands ──────────────────────────────────────────────────────────────────
@@ -180,6 +315,25 @@ func (a *App) cmdLoadNotes() tea.Cmd {
 	}
 }
 
+func (a *App) cmdStartWatch() tea.Cmd {
+	return func() tea.Msg {
+		w, err := a.store.Watch()
+		return watchStartedMsg{watcher: w, err: err}
+	}
+}
+
+// cmdWaitForChange blocks until the watcher reports a change on disk.
+func (a *App) cmdWaitForChange() tea.Cmd {
+	w := a.watcher
+	return func() tea.Msg {
+		ids, ok := <-w.Changes
+		if !ok {
+			return nil
+		}
+		return vaultChangedMsg{ids: ids}
+	}
+}
+
 func editorCmd(editor, path string) *exec.Cmd {
 	parts := strings.Fields(editor)
 	if len(parts) == 0 {
@@ -201,22 +355,130 @@ func (a *App) cmdOpenEditor(note *notes.Note) tea.Cmd {
 }
 
 func (a *App) cmdAskAI(note *notes.Note, question string) tea.Cmd {
-	return func() tea.Msg {
-		resp, err := a.ai.Ask(note.Title, note.Body, question)
-		return aiResponseMsg{response: resp, err: err}
-	}
+	ctx, cancel := context.WithCancel(context.Background())
+	a.aiCancel = cancel
+	a.aiStream++
+	id := a.aiStream
+	history := historyMessages(a.aiHistory)
+	return cmdStream(ctx,
+		func(onChunk func(string)) (string, error) {
+			return a.ai.Ask(ctx, note.Title, note.Body, history, question, onChunk)
+		},
+		func(text string, next tea.Cmd) tea.Msg { return aiChunkMsg{stream: id, text: text, next: next} },
+		func(resp string, err error) tea.Msg { return aiResponseMsg{stream: id, response: resp, err: err} },
+	)
 }
 
 func (a *App) cmdAskVault(question string) tea.Cmd {
 	all := a.allNotes
-	return func() tea.Msg {
-		ctx := make([]ai.NoteContext, len(all))
-		for i, n := range all {
-			ctx[i] = ai.NoteContext{Title: n.Title, Tags: n.Tags, Body: n.Body}
+	ctx, cancel := context.WithCancel(context.Background())
+	a.vaultAICancel = cancel
+	a.vaultAIStream++
+	id := a.vaultAIStream
+	history := historyMessages(a.vaultAIHistory)
+	var sources []ai.NoteContext
+	return cmdStream(ctx,
+		func(onChunk func(string)) (string, error) {
+			for _, p := range search.Retrieve(all, ai.FollowUp(history, question), ai.VaultPassages, ai.VaultTokens) {
+				sources = append(sources, ai.NoteContext{ID: p.Note.ID, Title: p.Note.Title, Section: p.Heading, Tags: p.Note.Tags, Body: p.Text})
+			}
+			return a.ai.AskVault(ctx, sources, history, question, onChunk)
+		},
+		func(text string, next tea.Cmd) tea.Msg { return vaultAIChunkMsg{stream: id, text: text, next: next} },
+		func(resp string, err error) tea.Msg {
+			return vaultAIResponseMsg{stream: id, response: resp, citations: ai.Citations(resp, sources), err: err}
+		},
+	)
+}
+
+// historyMessages turns the answered questions in entries into the
+// conversation to send with the next one. Unanswered and cancelled ones are
+// left out.
+func historyMessages(entries []aiEntry) []ai.Message {
+	var out []ai.Message
+	for _, e := range entries {
+		if e.answer == "" || e.cancelled {
+			continue
 		}
-		resp, err := a.ai.AskVault(ctx, question)
-		return vaultAIResponseMsg{response: resp, err: err}
+		out = append(out,
+			ai.Message{Role: ai.RoleUser, Content: e.question},
+			ai.Message{Role: ai.RoleAssistant, Content: e.answer},
+		)
+	}
+	return out
+}
+
+// maxLoggedEntries is how many earlier answers an AI panel reloads.
+const maxLoggedEntries = 20
+
+// loggedEntries returns the latest answers in the AI log about the note
+// id, or about the vault if id is "".
+func (a *App) loggedEntries(id string) []aiEntry {
+	logged, err := a.aiLog.ForNote(id)
+	if err != nil {
+		a.setStatus("AI log: "+err.Error(), true)
+		return nil
+	}
+	if len(logged) > maxLoggedEntries {
+		logged = logged[len(logged)-maxLoggedEntries:]
+	}
+	var out []aiEntry
+	for _, e := range logged {
+		out = append(out, aiEntry{question: e.Question, answer: e.Answer, sources: e.Sources, asked: e.Time})
+	}
+	return out
+}
+
+// logAnswer records e, asked about the note id (or the vault if ""), in
+// the AI log.
+func (a *App) logAnswer(id string, e aiEntry) {
+	err := a.aiLog.Append(ai.LogEntry{
+		Note:     id,
+		Time:     time.Now(),
+		Model:    a.ai.Model(),
+		Question: e.question,
+		Answer:   e.answer,
+		Sources:  e.sources,
+	})
+	if err != nil {
+		a.setStatus("AI log: "+err.Error(), true)
+	}
+}
+
+// askedAt labels an answer reloaded from the AI log with when it was asked.
+func askedAt(e aiEntry) string {
+	if e.asked.IsZero() {
+		return ""
 	}
+	return styleDimItem.Render("  · " + humanTime(e.asked))
+}
+
+// cmdStream runs ask in the background. Each chunk of the answer becomes a
+// message made by chunkMsg, carrying the command that waits for the next
+// one; the outcome becomes a message made by doneMsg. Once ctx is
+// cancelled nothing more is delivered.
+func cmdStream(ctx context.Context, ask func(onChunk func(string)) (string, error),
+	chunkMsg func(text string, next tea.Cmd) tea.Msg, doneMsg func(string, error) tea.Msg) tea.Cmd {
+	ch := make(chan tea.Msg)
+	next := func() tea.Msg {
+		msg, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+	go func() {
+		defer close(ch)
+		send := func(msg tea.Msg) {
+			select {
+			case ch <- msg:
+			case <-ctx.Done():
+			}
+		}
+		resp, err := ask(func(text string) { send(chunkMsg(text, next)) })
+		send(doneMsg(resp, err))
+	}()
+	return next
 }
 
 // ── Update ────────────────────────────────────────────────────────────────────
@@ -238,19 +500,25 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			a.setStatus("error loading notes: "+msg.err.Error(), true)
 			return a, nil
 		}
-		a.allNotes = msg.notes
-		a.filtered = msg.notes
-		if a.cursor >= len(a.filtered) {
-			a.cursor = max(0, len(a.filtered)-1)
+		a.applyNotes(msg.notes)
+
+	case watchStartedMsg:
+		if msg.err != nil {
+			a.setStatus("live reload off: "+msg.err.Error(), true)
+			return a, nil
 		}
+		a.watcher = msg.watcher
+		return a, a.cmdWaitForChange()
+
+	case vaultChangedMsg:
+		return a, tea.Batch(a.cmdLoadNotes(), a.cmdWaitForChange())
 
 	case editorClosedMsg:
 		if msg.err != nil {
 			a.setStatus("editor: "+msg.err.Error(), true)
 		}
 		if msg.notes != nil {
-			a.allNotes = msg.notes
-			a.filtered = msg.notes
+			a.applyNotes(msg.notes)
 		}
 		if msg.openID != "" {
 			for _, n := range msg.notes {
@@ -265,21 +533,68 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.lastKey = ""
 		a.state = stateList
 
+	case aiChunkMsg:
+		if msg.stream != a.aiStream || !a.aiLoading {
+			return a, nil
+		}
+		if len(a.aiHistory) > 0 {
+			a.aiHistory[len(a.aiHistory)-1].answer += msg.text
+		}
+		return a, msg.next
+
 	case aiResponseMsg:
+		if msg.stream != a.aiStream || !a.aiLoading {
+			return a, nil
+		}
 		a.aiLoading = false
+		a.aiCancel()
 		if msg.err != nil {
 			a.aiError = msg.err.Error()
-		} else if len(a.aiHistory) > 0 {
-			a.aiHistory[len(a.aiHistory)-1].answer = msg.response
+		} else if len(a.aiHistory) > 0 && a.current != nil {
+			last := &a.aiHistory[len(a.aiHistory)-1]
+			last.answer = msg.response
+			a.logAnswer(a.current.ID, *last)
+		}
+
+	case vaultAIChunkMsg:
+		if msg.stream != a.vaultAIStream || !a.vaultAILoading {
+			return a, nil
+		}
+		if len(a.vaultAIHistory) > 0 {
+			a.vaultAIHistory[len(a.vaultAIHistory)-1].answer += msg.text
 		}
+		return a, msg.next
 
 	case vaultAIResponseMsg:
+		if msg.stream != a.vaultAIStream || !a.vaultAILoading {
+			return a, nil
+		}
 		a.vaultAILoading = false
+		a.vaultAICancel()
 		if msg.err != nil {
 			a.vaultAIError = msg.err.Error()
 		} else if len(a.vaultAIHistory) > 0 {
-			a.vaultAIHistory[len(a.vaultAIHistory)-1].answer = msg.response
+			last := &a.vaultAIHistory[len(a.vaultAIHistory)-1]
+			last.answer = msg.response
+			last.sources = msg.citations
+			a.logAnswer("", *last)
+		}
+
+	case tagsSuggestedMsg:
+		if msg.run != a.tagRun || msg.index >= len(a.tagReviews) {
+			return a, nil
+		}
+		r := &a.tagReviews[msg.index]
+		r.done = true
+		if msg.err != nil {
+			r.err = msg.err.Error()
+		}
+		r.suggestions = msg.suggestions
+		r.chosen = make([]bool, len(msg.suggestions)) // nothing until reviewed
+		if msg.index+1 < len(a.tagReviews) {
+			return a, a.cmdSuggestTags(msg.index + 1)
 		}
+		a.tagCancel()
 
 	case tea.KeyMsg:
 		a.statusMsg = ""
@@ -307,6 +622,20 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return a.updateLinks(msg)
 		case stateVaultAI:
 			return a.updateVaultAI(msg)
+		case stateConflict:
+			return a.updateConflict(msg)
+		case stateRename:
+			return a.updateRename(msg)
+		case stateRenamePreview:
+			return a.updateRenamePreview(msg)
+		case stateDoctor:
+			return a.updateDoctor(msg)
+		case stateCreateLink:
+			return a.updateCreateLink(msg)
+		case stateGraph:
+			return a.updateGraph(msg)
+		case stateTags:
+			return a.updateTags(msg)
 		}
 	}
 
@@ -316,6 +645,9 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 // ── List ──────────────────────────────────────────────────────────────────────
 
 func (a *App) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if a.sidebarFocus {
+		return a.updateSidebar(msg)
+	}
 	prev := a.lastKey
 	a.lastKey = msg.String()
 
@@ -323,8 +655,18 @@ func (a *App) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "q", "ctrl+c":
 		return a, tea.Quit
 
+	case "tab":
+		if a.showSidebar() {
+			a.sidebarFocus = true
+		}
+
+	case "esc":
+		if a.activeSaved != "" {
+			a.applySaved("")
+		}
+
 	case "j", "down":
-		if a.cursor < len(a.filtered)-1 {
+		if a.cursor < a.listLen()-1 {
 			a.cursor++
 			a.ensureVisible()
 		}
@@ -343,17 +685,44 @@ func (a *App) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 
 	case "G":
-		if len(a.filtered) > 0 {
-			a.cursor = len(a.filtered) - 1
+		if a.listLen() > 0 {
+			a.cursor = a.listLen() - 1
 			a.ensureVisible()
 		}
 
 	case "enter", "l":
-		if len(a.filtered) > 0 {
-			a.openNote(a.filtered[a.cursor])
+		if row := a.selectedRow(); row != nil && row.note == nil {
+			a.collapsed[row.folder] = !a.collapsed[row.folder]
+			a.refreshRows()
+		} else if n := a.selectedNote(); n != nil {
+			a.openNote(n)
+		}
+
+	case "h":
+		// Collapse the folder under (or containing) the cursor
+		if row := a.selectedRow(); row != nil {
+			folder := row.folder
+			if row.note != nil {
+				folder = row.note.Folder
+			}
+			if folder != "" {
+				a.collapsed[folder] = true
+				a.refreshRows()
+				a.moveCursorToFolder(folder)
+			}
 		}
 
+	case "f":
+		a.treeMode = !a.treeMode
+		id := ""
+		if n := a.selectedNote(); n != nil {
+			id = n.ID
+		}
+		a.refreshRows()
+		a.moveCursorTo(id)
+
 	case "n":
+		a.newNoteFolder = a.selectedFolder()
 		a.state = stateNewNote
 		a.newNoteInput.SetValue("")
 		a.newNoteInput.Focus()
@@ -361,6 +730,7 @@ func (a *App) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	case "N":
 		// New note with template picker
+		a.newNoteFolder = a.selectedFolder()
 		a.state = stateTemplatePicker
 		a.templateCursor = 0
 
@@ -383,17 +753,36 @@ func (a *App) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return a, textinput.Blink
 
 	case "d":
-		if len(a.filtered) > 0 {
-			a.deleteTarget = a.filtered[a.cursor]
+		if n := a.selectedNote(); n != nil {
+			a.deleteTarget = n
 			a.state = stateConfirmDelete
 		}
 
 	case "r":
 		return a, a.cmdLoadNotes()
 
+	case "R":
+		if n := a.selectedNote(); n != nil {
+			return a, a.startRename(n)
+		}
+
+	case "T":
+		untagged := notes.Untagged(a.allNotes)
+		if len(untagged) == 0 {
+			a.setStatus("every note has tags", false)
+			return a, nil
+		}
+		more := max(0, len(untagged)-maxTagBatch)
+		return a, a.startTagReview(untagged[:len(untagged)-more], more)
+
+	case "D":
+		a.problems = notes.Check(a.allNotes)
+		a.doctorCursor = 0
+		a.state = stateDoctor
+
 	case "@":
-		if !a.ai.Available() {
-			a.setStatus("no Gemini API key — check ~/.config/pairy/config.json", true)
+		if err := a.ai.Ready(); err != nil {
+			a.setStatus(err.Error(), true)
 			return a, nil
 		}
 		a.state = stateVaultAI
@@ -420,6 +809,9 @@ func (a *App) updateViewer(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "q", "h", "esc":
 		a.state = stateList
+		if a.current != nil {
+			a.moveCursorTo(a.current.ID)
+		}
 
 	case "e":
 		if a.current != nil {
@@ -427,8 +819,8 @@ func (a *App) updateViewer(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 
 	case "A", "a":
-		if !a.ai.Available() {
-			a.setStatus("no Gemini API key — check ~/.config/pairy/config.json", true)
+		if err := a.ai.Ready(); err != nil {
+			a.setStatus(err.Error(), true)
 			return a, nil
 		}
 		a.state = stateAIPanel
@@ -443,6 +835,21 @@ func (a *App) updateViewer(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			a.openLinksPanel()
 		}
 
+	case "R":
+		if a.current != nil {
+			return a, a.startRename(a.current)
+		}
+
+	case "M":
+		if a.current != nil {
+			a.openGraph(a.current)
+		}
+
+	case "T":
+		if a.current != nil {
+			return a, a.startTagReview([]*notes.Note{a.current}, 0)
+		}
+
 	case "g":
 		if prev == "g" {
 			a.viewport.GotoTop()
@@ -490,14 +897,16 @@ func (a *App) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "esc":
 		a.state = stateList
-		a.filtered = a.allNotes
+		a.filtered = a.listNotes()
 		a.cursor = 0
+		a.refreshRows()
 		a.searchInput.Blur()
 		return a, nil
 
 	case "enter":
 		if len(a.filtered) > 0 {
 			a.openNote(a.filtered[a.cursor])
+			a.refreshRows()
 		}
 		return a, nil
 
@@ -526,22 +935,114 @@ func (a *App) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func (a *App) runSearch(query string) {
+	a.searchErr = ""
 	if query == "" {
+		a.searchResults = nil
 		a.filtered = a.allNotes
 		return
 	}
-	targets := make([]string, len(a.allNotes))
-	for i, n := range a.allNotes {
-		targets[i] = n.Title + " " + strings.Join(n.Tags, " ") + " " + n.Body
+	rs, err := a.runQuery(query)
+	if err != nil {
+		// Usually a half-typed filter; keep the last results until it parses
+		a.searchErr = err.Error()
+		return
 	}
-	matches := fuzzy.Find(query, targets)
-	result := make([]*notes.Note, 0, len(matches))
-	for _, m := range matches {
-		result = append(result, a.allNotes[m.Index])
+	a.searchResults = rs
+	result := make([]*notes.Note, len(a.searchResults))
+	for i, r := range a.searchResults {
+		result[i] = r.Note
 	}
 	a.filtered = result
 }
 
+// runQuery runs query, with @name references expanded, against the search
+// index. The index is built on first use after each reload.
+func (a *App) runQuery(query string) ([]search.Result, error) {
+	expanded, err := search.ExpandSaved(query, a.cfg.SavedSearch)
+	if err != nil {
+		return nil, err
+	}
+	if a.searchIdx == nil {
+		a.searchIdx = search.Build(a.allNotes)
+	}
+	return a.searchIdx.Search(expanded, 0)
+}
+
+// ── Saved searches ────────────────────────────────────────────────────────────
+
+func (a *App) updateSidebar(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return a, tea.Quit
+
+	case "tab", "esc":
+		a.sidebarFocus = false
+
+	case "j", "down":
+		if a.sidebarCursor < len(a.cfg.SavedSearches)-1 {
+			a.sidebarCursor++
+		}
+
+	case "k", "up":
+		if a.sidebarCursor > 0 {
+			a.sidebarCursor--
+		}
+
+	case "enter", "l":
+		if a.sidebarCursor < len(a.cfg.SavedSearches) {
+			a.applySaved(a.cfg.SavedSearches[a.sidebarCursor].Name)
+			a.sidebarFocus = false
+		}
+	}
+	return a, nil
+}
+
+// applySaved filters the list by the named saved search; "" shows all notes.
+func (a *App) applySaved(name string) {
+	a.activeSaved = name
+	a.filtered = a.listNotes()
+	a.cursor = 0
+	a.listOffset = 0
+	a.refreshRows()
+}
+
+// listNotes returns the notes the list shows outside search: the active
+// saved search's matches, or every note.
+func (a *App) listNotes() []*notes.Note {
+	if a.activeSaved == "" {
+		return a.allNotes
+	}
+	query, ok := a.cfg.SavedSearch(a.activeSaved)
+	if !ok {
+		a.activeSaved = ""
+		return a.allNotes
+	}
+	rs, err := a.runQuery(query)
+	if err != nil {
+		a.setStatus("@"+a.activeSaved+": "+err.Error(), true)
+		a.activeSaved = ""
+		return a.allNotes
+	}
+	out := make([]*notes.Note, len(rs))
+	for i, r := range rs {
+		out[i] = r.Note
+	}
+	return out
+}
+
+// countSaved refreshes the match count shown next to each saved search.
+func (a *App) countSaved() {
+	a.savedCounts = a.savedCounts[:0]
+	for _, s := range a.cfg.SavedSearches {
+		rs, err := a.runQuery(s.Query)
+		if err != nil {
+			a.savedCounts = append(a.savedCounts, 0)
+			continue
+		}
+		a.savedCounts = append(a.savedCounts, len(rs))
+	}
+}
+
 // ── New Note ──────────────────────────────────────────────────────────────────
 
 func (a *App) updateNewNote(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
@@ -558,7 +1059,7 @@ func (a *App) updateNewNote(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			a.state = stateList
 			return a, nil
 		}
-		note, err := a.store.Create(title, nil)
+		note, err := a.store.CreateIn(a.newNoteFolder, title, nil)
 		if err != nil {
 			a.setStatus("error: "+err.Error(), true)
 			a.state = stateList
@@ -615,7 +1116,7 @@ func (a *App) updateTemplateTitle(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			a.state = stateList
 			return a, nil
 		}
-		note, err := a.store.Create(title, nil)
+		note, err := a.store.CreateIn(a.newNoteFolder, title, nil)
 		if err != nil {
 			a.setStatus("error: "+err.Error(), true)
 			a.state = stateList
@@ -623,9 +1124,8 @@ func (a *App) updateTemplateTitle(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		date := time.Now().Format("2006-01-02")
 		note.Body = templates.Get(a.selectedTemplate, title, date)
-		if err := a.store.Save(note); err != nil {
-			a.setStatus("save error: "+err.Error(), true)
-			a.state = stateList
+		a.state = stateList
+		if !a.saveNote(note) {
 			return a, nil
 		}
 		return a, a.cmdOpenEditor(note)
@@ -639,12 +1139,26 @@ func (a *App) updateTemplateTitle(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 // ── AI Panel (per-note) ───────────────────────────────────────────────────────
 
 func (a *App) updateAIPanel(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if a.aiSaving {
+		return a, a.updateSaveAnswer(msg, a.aiHistory, a.current)
+	}
 	switch msg.String() {
+	case "ctrl+s":
+		a.startSaveAnswer(a.aiHistory, a.aiLoading)
+		return a, nil
+
 	case "esc":
-		if !a.aiLoading {
-			a.state = stateViewer
-			a.aiInput.Blur()
+		if a.aiLoading {
+			// Stop the answer; what arrived so far stays
+			a.aiCancel()
+			a.aiLoading = false
+			if len(a.aiHistory) > 0 {
+				a.aiHistory[len(a.aiHistory)-1].cancelled = true
+			}
+			return a, nil
 		}
+		a.state = stateViewer
+		a.aiInput.Blur()
 		return a, nil
 
 	case "enter":
@@ -667,6 +1181,59 @@ func (a *App) updateAIPanel(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return a, nil
 }
 
+// startSaveAnswer asks where to save the last answer in history.
+func (a *App) startSaveAnswer(history []aiEntry, loading bool) {
+	switch {
+	case loading:
+		a.setStatus("wait for the answer to finish (or Esc) before saving", true)
+	case lastAnswer(history) == nil:
+		a.setStatus("no answer to save yet", true)
+	default:
+		a.aiSaving = true
+	}
+}
+
+// updateSaveAnswer handles the key picking where the last answer goes:
+// appended to about, a new note, or today's daily note.
+func (a *App) updateSaveAnswer(msg tea.KeyMsg, history []aiEntry, about *notes.Note) tea.Cmd {
+	a.aiSaving = false
+	key := msg.String()
+	switch key {
+	case string(saveAppend), string(saveNew), string(saveDaily):
+		e := lastAnswer(history)
+		if e == nil || a.saveAnswer(*e, rune(key[0]), about) == nil {
+			return nil
+		}
+		return a.cmdLoadNotes()
+	}
+	return nil
+}
+
+// saveAnswerHint lists the save targets, naming the note "a" appends to.
+func saveAnswerHint(about *notes.Note) string {
+	hint := "  save answer:  "
+	if about != nil {
+		hint += "a append to " + about.Title + "  "
+	}
+	return hint + "n new note  t today's note  Esc cancel"
+}
+
+// aiPanelHint is the bottom line of an AI panel: the save prompt, the
+// status message or hint.
+func (a *App) aiPanelHint(about *notes.Note, hint string) string {
+	switch {
+	case a.aiSaving:
+		return styleConfirm.Render(saveAnswerHint(about))
+	case a.statusMsg != "":
+		sty := styleSuccess
+		if a.statusIsError {
+			sty = styleError
+		}
+		return sty.Render("  " + a.statusMsg)
+	}
+	return styleHint.Render(hint)
+}
+
 // ── Confirm Delete ────────────────────────────────────────────────────────────
 
 func (a *App) updateConfirmDelete(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
@@ -691,87 +1258,701 @@ func (a *App) updateConfirmDelete(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return a, nil
 }
 
-// ── Help ──────────────────────────────────────────────────────────────────────
+// ── Rename ────────────────────────────────────────────────────────────────────
 
-func (a *App) updateHelp(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+func (a *App) startRename(n *notes.Note) tea.Cmd {
+	a.renameTarget = n
+	a.renameReturn = a.state
+	a.state = stateRename
+	a.renameInput.SetValue(n.Title)
+	a.renameInput.CursorEnd()
+	a.renameInput.Focus()
+	return textinput.Blink
+}
+
+func (a *App) updateRename(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
-	case "q", "esc", "?":
-		a.state = a.prevState
+	case "esc":
+		a.renameInput.Blur()
+		a.state = a.renameReturn
+		return a, nil
+
+	case "enter":
+		title := strings.TrimSpace(a.renameInput.Value())
+		if title == "" || title == a.renameTarget.Title {
+			a.renameInput.Blur()
+			a.state = a.renameReturn
+			return a, nil
+		}
+		plan, err := a.store.PlanRename(a.renameTarget.ID, title)
+		if err != nil {
+			a.setStatus("rename: "+err.Error(), true)
+			return a, nil
+		}
+		a.renameInput.Blur()
+		a.renamePlan = plan
+		a.state = stateRenamePreview
+		return a, nil
 	}
-	return a, nil
+
+	var cmd tea.Cmd
+	a.renameInput, cmd = a.renameInput.Update(msg)
+	return a, cmd
 }
 
-// ── Links Panel ───────────────────────────────────────────────────────────────
+func (a *App) updateRenamePreview(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y", "enter":
+		p := a.renamePlan
+		a.renamePlan = nil
+		a.state = a.renameReturn
+		done, err := a.store.Rename(p.OldID, p.NewTitle)
+		if err != nil {
+			var ce *notes.ConflictError
+			if errors.As(err, &ce) {
+				a.setStatus(ce.Note.Title+" changed on disk — review it and rename again", true)
+			} else {
+				a.setStatus("rename: "+err.Error(), true)
+			}
+			return a, a.cmdLoadNotes()
+		}
+		if a.current != nil && a.current.ID == p.OldID {
+			a.current = done.Note
+			a.reRender()
+		}
+		a.setStatus(fmt.Sprintf("renamed to %s, %d links updated", done.NewTitle, done.Links()), false)
+		return a, a.cmdLoadNotes()
 
-func (a *App) openLinksPanel() {
-	if a.current == nil {
-		return
+	case "n", "N", "esc", "q":
+		a.renamePlan = nil
+		a.state = stateRename
+		a.renameInput.Focus()
+		return a, textinput.Blink
 	}
-	a.linksOut = notes.ExtractLinks(a.current.Body)
-	a.linksBack = notes.Backlinks(a.current.Title, a.allNotes)
-	a.linksCursor = 0
-	a.state = stateLinks
+	return a, nil
 }
 
-func (a *App) updateLinks(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	totalEntries := len(a.linksOut) + len(a.linksBack)
+// ── Doctor ────────────────────────────────────────────────────────────────────
 
+func (a *App) updateDoctor(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "esc", "q", "h":
-		a.state = stateViewer
-		return a, nil
+		a.state = stateList
 
 	case "j", "down":
-		if a.linksCursor < totalEntries-1 {
-			a.linksCursor++
+		if a.doctorCursor < len(a.problems)-1 {
+			a.doctorCursor++
 		}
 
 	case "k", "up":
-		if a.linksCursor > 0 {
-			a.linksCursor--
+		if a.doctorCursor > 0 {
+			a.doctorCursor--
 		}
 
 	case "enter", "l":
-		// Determine which note to open
-		var targetTitle string
-		if a.linksCursor < len(a.linksOut) {
-			targetTitle = a.linksOut[a.linksCursor]
-		} else {
-			idx := a.linksCursor - len(a.linksOut)
-			if idx < len(a.linksBack) {
-				// open by ID
-				a.openNote(a.linksBack[idx])
-				return a, nil
+		if a.doctorCursor < len(a.problems) {
+			p := a.problems[a.doctorCursor]
+			a.openNote(p.Note)
+			if p.Kind == notes.BrokenLink {
+				a.scrollToLink(p.Link)
 			}
 		}
-		if targetTitle != "" {
-			// find note by title
-			for _, n := range a.allNotes {
-				if strings.EqualFold(n.Title, targetTitle) {
-					a.openNote(n)
-					return a, nil
-				}
+
+	case "s":
+		// Create stub notes for every missing link target
+		dangling := notes.Dangling(a.problems)
+		if len(dangling) == 0 {
+			a.setStatus("no missing link targets", false)
+			return a, nil
+		}
+		for _, d := range dangling {
+			if _, err := a.store.CreateStub(d.Title, d.From); err != nil {
+				a.setStatus("stub "+d.Title+": "+err.Error(), true)
+				return a, a.cmdLoadNotes()
 			}
-			a.setStatus("note not found: "+targetTitle, true)
-			a.state = stateViewer
 		}
+		a.setStatus(fmt.Sprintf("created %d stub notes", len(dangling)), false)
+		return a, a.cmdLoadNotes()
 	}
-
 	return a, nil
 }
 
-// ── Vault AI ──────────────────────────────────────────────────────────────────
+// scrollToLink scrolls the viewer to the first line of the current note
+// containing l.
+func (a *App) scrollToLink(l notes.Link) {
+	a.scrollToText(l.Target)
+}
 
-func (a *App) updateVaultAI(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "esc":
-		if !a.vaultAILoading {
-			a.state = stateList
-			a.vaultAIInput.Blur()
+// scrollToText scrolls the viewer to the first rendered line containing s.
+func (a *App) scrollToText(s string) {
+	for i, line := range a.renderedLines {
+		if strings.Contains(ansi.Strip(line), s) {
+			a.viewport.SetYOffset(i)
+			return
 		}
-		return a, nil
+	}
+}
 
-	case "enter":
+// ── Help ──────────────────────────────────────────────────────────────────────
+
+func (a *App) updateHelp(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc", "?":
+		a.state = a.prevState
+	}
+	return a, nil
+}
+
+// ── Links Panel ───────────────────────────────────────────────────────────────
+
+func (a *App) openLinksPanel() {
+	if a.current == nil {
+		return
+	}
+	a.linksCursor = 0
+	a.refreshLinks()
+	a.state = stateLinks
+}
+
+// refreshLinks recomputes the links panel for the current note.
+func (a *App) refreshLinks() {
+	a.linksOut = a.current.Links
+	a.linksBack = notes.Backlinks(a.current, a.allNotes, a.noteResolver())
+	a.linksMentions = notes.UnlinkedMentions(a.current, a.allNotes)
+	total := len(a.linksOut) + len(a.linksBack) + len(a.linksMentions)
+	a.linksCursor = min(a.linksCursor, max(0, total-1))
+}
+
+func (a *App) updateLinks(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	totalEntries := len(a.linksOut) + len(a.linksBack) + len(a.linksMentions)
+
+	switch msg.String() {
+	case "esc", "q", "h":
+		a.state = stateViewer
+		return a, nil
+
+	case "j", "down":
+		if a.linksCursor < totalEntries-1 {
+			a.linksCursor++
+		}
+
+	case "k", "up":
+		if a.linksCursor > 0 {
+			a.linksCursor--
+		}
+
+	case "enter", "l":
+		if a.linksCursor < len(a.linksOut) {
+			a.followLink(a.linksOut[a.linksCursor])
+			return a, nil
+		}
+		idx := a.linksCursor - len(a.linksOut)
+		if idx < len(a.linksBack) {
+			// open by ID
+			a.openNote(a.linksBack[idx])
+			return a, nil
+		}
+		idx -= len(a.linksBack)
+		if idx < len(a.linksMentions) {
+			m := a.linksMentions[idx]
+			a.openNote(m.Note)
+			a.scrollToText(m.Text)
+		}
+
+	case "m":
+		idx := a.linksCursor - len(a.linksOut) - len(a.linksBack)
+		if idx >= 0 && idx < len(a.linksMentions) {
+			return a, a.linkMention(a.linksMentions[idx])
+		}
+	}
+
+	return a, nil
+}
+
+// linkMention turns an unlinked mention of the current note into a
+// wiki-link in the note that contains it.
+func (a *App) linkMention(m notes.Mention) tea.Cmd {
+	note, err := a.store.Load(m.Note.ID)
+	if err != nil {
+		a.setStatus("error: "+err.Error(), true)
+		return nil
+	}
+	body, ok := notes.LinkMention(note.Body, m, a.current)
+	if !ok {
+		a.setStatus(note.Title+" changed since the panel opened — try again", true)
+		return a.cmdLoadNotes()
+	}
+	note.Body = body
+	if !a.saveNote(note) {
+		return nil
+	}
+
+	// The note's other mentions moved; find them again in the saved body
+	var mentions []notes.Mention
+	for i, other := range a.linksMentions {
+		switch {
+		case other.Note.ID != note.ID:
+			mentions = append(mentions, other)
+		case i == 0 || a.linksMentions[i-1].Note.ID != note.ID:
+			mentions = append(mentions, notes.UnlinkedMentions(a.current, []*notes.Note{note})...)
+		}
+	}
+	a.linksMentions = mentions
+	total := len(a.linksOut) + len(a.linksBack) + len(a.linksMentions)
+	a.linksCursor = min(a.linksCursor, max(0, total-1))
+	a.setStatus("linked "+m.Text+" in "+note.Title, false)
+	return a.cmdLoadNotes()
+}
+
+// followLink opens the note l points at and scrolls to its heading or
+// block, if it names one.
+func (a *App) followLink(l notes.Link) {
+	target := a.current
+	if l.Target != "" {
+		target = a.resolve(l.Target)
+	}
+	if target == nil {
+		// Offer to create it, the way wikis do
+		a.createLink = l
+		a.createCursor = 0
+		a.state = stateCreateLink
+		return
+	}
+	a.openNote(target)
+	if (l.Heading != "" || l.Block != "") && !a.scrollToAnchor(l) {
+		a.setStatus("no such section in "+target.Title+": "+l.String(), true)
+	}
+}
+
+func (a *App) updateCreateLink(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "n":
+		a.state = stateLinks
+
+	case "j", "down":
+		if a.createCursor < len(templates.Names)-1 {
+			a.createCursor++
+		}
+
+	case "k", "up":
+		if a.createCursor > 0 {
+			a.createCursor--
+		}
+
+	case "enter", "y", "l":
+		l := a.createLink
+		folder := ""
+		if a.current != nil {
+			folder = a.current.Folder
+		}
+		note, err := a.store.CreateIn(folder, l.Target, nil)
+		if err != nil {
+			a.setStatus("error: "+err.Error(), true)
+			a.state = stateLinks
+			return a, nil
+		}
+		date := time.Now().Format("2006-01-02")
+		note.Body = templates.Get(templates.Names[a.createCursor], l.Target, date)
+		if l.Heading != "" {
+			// Give [[Note#Heading]] somewhere to land
+			note.Body = strings.TrimRight(note.Body, "\n") + "\n\n## " + l.Heading + "\n"
+			note.Body = strings.TrimLeft(note.Body, "\n")
+		}
+		a.state = stateViewer
+		if !a.saveNote(note) {
+			return a, nil
+		}
+		return a, a.cmdOpenEditor(note)
+	}
+	return a, nil
+}
+
+// scrollToAnchor scrolls the viewer to the heading or block l names and
+// reports whether it was found.
+func (a *App) scrollToAnchor(l notes.Link) bool {
+	if i := anchorLine(a.renderedLines, l); i != -1 {
+		a.viewport.SetYOffset(i)
+		return true
+	}
+	return false
+}
+
+// anchorLine returns the index of the rendered line holding the block or
+// heading l names, or -1. Headings are matched on lines that glamour
+// prefixes with #s first, then on any line with exactly the heading text,
+// since top-level headings render without the #.
+func anchorLine(lines []string, l notes.Link) int {
+	if l.Block != "" {
+		for i, line := range lines {
+			if strings.HasSuffix(strings.TrimSpace(ansi.Strip(line)), "^"+l.Block) {
+				return i
+			}
+		}
+		return -1
+	}
+	for _, hashed := range []bool{true, false} {
+		for i, line := range lines {
+			text := strings.TrimSpace(ansi.Strip(line))
+			if strings.HasPrefix(text, "#") != hashed {
+				continue
+			}
+			if strings.EqualFold(strings.TrimSpace(strings.TrimLeft(text, "#")), l.Heading) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// ── Graph ─────────────────────────────────────────────────────────────────────
+
+// maxGraphHops bounds the neighborhood view; past a few hops most vaults
+// are one big component anyway.
+const maxGraphHops = 5
+
+// openGraph shows the link neighborhood of n.
+func (a *App) openGraph(n *notes.Note) {
+	if a.graphHops == 0 {
+		a.graphHops = 1
+	}
+	a.graphCenter = n
+	a.graphCursor = 0
+	a.refreshGraph()
+	a.state = stateGraph
+}
+
+// refreshGraph lays out the neighborhood again, keeping the cursor on the
+// same note where it can.
+func (a *App) refreshGraph() {
+	if a.graph == nil {
+		a.graph = notes.BuildGraph(a.allNotes)
+	}
+	selectedID := ""
+	if a.graphCursor < len(a.graphRows) {
+		selectedID = a.graphRows[a.graphCursor].note.ID
+	}
+
+	// The center may be a freshly loaded copy; find the graph's own note
+	var center *notes.Note
+	for _, n := range a.graph.Notes {
+		if n.ID == a.graphCenter.ID {
+			center = n
+			break
+		}
+	}
+	if center == nil {
+		a.graphRows = nil
+		a.graphCursor = 0
+		return
+	}
+	a.graphCenter = center
+	a.graphRows = buildNeighborhood(a.graph, center, a.graphHops)
+	a.graphCursor = 0
+	for i, r := range a.graphRows {
+		if r.note.ID == selectedID {
+			a.graphCursor = i
+			break
+		}
+	}
+}
+
+func (a *App) updateGraph(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "h":
+		a.state = stateViewer
+
+	case "j", "down":
+		if a.graphCursor < len(a.graphRows)-1 {
+			a.graphCursor++
+		}
+
+	case "k", "up":
+		if a.graphCursor > 0 {
+			a.graphCursor--
+		}
+
+	case "+", "=":
+		if a.graphHops < maxGraphHops {
+			a.graphHops++
+			a.refreshGraph()
+		}
+
+	case "-":
+		if a.graphHops > 1 {
+			a.graphHops--
+			a.refreshGraph()
+		}
+
+	case "c", " ":
+		// Re-center on the selected note
+		if a.graphCursor < len(a.graphRows) {
+			a.graphCenter = a.graphRows[a.graphCursor].note
+			a.graphCursor = 0
+			a.refreshGraph()
+		}
+
+	case "enter", "l":
+		if a.graphCursor < len(a.graphRows) {
+			a.openNote(a.graphRows[a.graphCursor].note)
+		}
+	}
+	return a, nil
+}
+
+// ── Tag Suggestions ───────────────────────────────────────────────────────────
+
+// maxTagBatch bounds how many untagged notes one review asks about.
+const maxTagBatch = 20
+
+// tagReview is one note on the tag review screen.
+type tagReview struct {
+	note        *notes.Note
+	suggestions []ai.TagSuggestion
+	chosen      []bool // parallel to suggestions
+	done        bool   // the AI has answered
+	err         string
+}
+
+// tagRow is a line of the review screen the cursor can be on.
+type tagRow struct {
+	review, suggestion int
+}
+
+// startTagReview asks the AI for tags for each of ns in turn and shows the
+// review screen. more is how many untagged notes were left for a later run.
+func (a *App) startTagReview(ns []*notes.Note, more int) tea.Cmd {
+	if err := a.ai.Ready(); err != nil {
+		a.setStatus(err.Error(), true)
+		return nil
+	}
+	a.tagReviews = make([]tagReview, len(ns))
+	for i, n := range ns {
+		a.tagReviews[i] = tagReview{note: n}
+	}
+	a.tagMore = more
+	a.tagVocab = notes.Vocabulary(a.allNotes)
+	a.tagCursor = 0
+	a.tagReturn = a.state
+	a.state = stateTags
+	a.tagRun++
+	return a.cmdSuggestTags(0)
+}
+
+// cmdSuggestTags asks for the tags of tagReviews[i]; tagCancel stops it.
+func (a *App) cmdSuggestTags(i int) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	a.tagCancel = cancel
+	run, n := a.tagRun, a.tagReviews[i].note
+	vocab := make([]string, len(a.tagVocab))
+	for i, tc := range a.tagVocab {
+		vocab[i] = tc.Tag
+	}
+	return func() tea.Msg {
+		sg, err := a.ai.SuggestTags(ctx, n.Title, n.Body, n.Tags, vocab)
+		return tagsSuggestedMsg{run: run, index: i, suggestions: sg, err: err}
+	}
+}
+
+// tagRows lists the suggestions the cursor can move over.
+func (a *App) tagRows() []tagRow {
+	var rows []tagRow
+	for i, r := range a.tagReviews {
+		for j := range r.suggestions {
+			rows = append(rows, tagRow{i, j})
+		}
+	}
+	return rows
+}
+
+// stopTags cancels the run, if any, and leaves the review screen.
+func (a *App) stopTags() {
+	a.tagRun++
+	if a.tagCancel != nil {
+		a.tagCancel()
+	}
+	a.state = a.tagReturn
+}
+
+func (a *App) updateTags(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	rows := a.tagRows()
+	switch msg.String() {
+	case "esc", "q":
+		a.stopTags()
+
+	case "j", "down":
+		if a.tagCursor < len(rows)-1 {
+			a.tagCursor++
+		}
+
+	case "k", "up":
+		if a.tagCursor > 0 {
+			a.tagCursor--
+		}
+
+	case " ", "x":
+		if a.tagCursor < len(rows) {
+			row := rows[a.tagCursor]
+			r := &a.tagReviews[row.review]
+			r.chosen[row.suggestion] = !r.chosen[row.suggestion]
+		}
+
+	case "enter":
+		a.stopTags()
+		return a, a.applyTags()
+	}
+	return a, nil
+}
+
+// applyTags adds the chosen tags to each note's frontmatter.
+func (a *App) applyTags() tea.Cmd {
+	tagged := 0
+	for _, r := range a.tagReviews {
+		if !slices.Contains(r.chosen, true) {
+			continue
+		}
+		n, err := a.store.Load(r.note.ID)
+		if err == nil {
+			for j, sg := range r.suggestions {
+				if r.chosen[j] && !notes.HasTag(n, sg.Tag) {
+					n.Tags = append(n.Tags, sg.Tag)
+				}
+			}
+			err = a.store.Save(n)
+		}
+		if err != nil {
+			a.setStatus("tagging "+r.note.Title+": "+err.Error(), true)
+			return a.cmdLoadNotes()
+		}
+		if a.current != nil && a.current.ID == n.ID {
+			a.current = n
+			a.reRender()
+		}
+		tagged++
+	}
+	switch tagged {
+	case 0:
+		a.setStatus("no tags added", false)
+		return nil
+	case 1:
+		a.setStatus("tagged 1 note", false)
+	default:
+		a.setStatus(fmt.Sprintf("tagged %d notes", tagged), false)
+	}
+	return a.cmdLoadNotes()
+}
+
+// ── Save Conflict ─────────────────────────────────────────────────────────────
+
+// saveNote saves note and reports whether it was written. If the file changed
+// on disk since it was loaded, the conflict prompt is shown instead and the
+// caller should stop; the prompt returns to the current state when resolved.
+func (a *App) saveNote(note *notes.Note) bool {
+	err := a.store.Save(note)
+	if err == nil {
+		return true
+	}
+	var ce *notes.ConflictError
+	if errors.As(err, &ce) {
+		a.conflict = ce
+		a.conflictReturn = a.state
+		a.state = stateConflict
+		return false
+	}
+	a.setStatus("save error: "+err.Error(), true)
+	return false
+}
+
+func (a *App) updateConflict(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	ce := a.conflict
+	if ce == nil {
+		a.state = stateList
+		return a, nil
+	}
+
+	switch msg.String() {
+	case "o", "O":
+		// Keep my version
+		a.resolveConflict()
+		if err := a.store.Overwrite(ce.Note); err != nil {
+			a.setStatus("save error: "+err.Error(), true)
+			return a, nil
+		}
+		a.setStatus("overwrote "+ce.Note.Title, false)
+		a.refreshCurrent(ce.Note)
+		return a, a.cmdLoadNotes()
+
+	case "r", "R":
+		// Discard my changes and take the disk version
+		a.resolveConflict()
+		a.setStatus("reloaded "+ce.Disk.Title+" from disk", false)
+		a.refreshCurrent(ce.Disk)
+		return a, a.cmdLoadNotes()
+
+	case "m", "M":
+		if !notes.MergeFront(ce.Note, ce.Disk) {
+			a.setStatus("both versions changed the same frontmatter field — press r to reload or o to overwrite", true)
+			return a, nil
+		}
+		a.resolveConflict()
+		body, markers := notes.Merge(ce.Note, ce.Disk)
+		ce.Note.Body = body
+		if err := a.store.Overwrite(ce.Note); err != nil {
+			a.setStatus("save error: "+err.Error(), true)
+			return a, nil
+		}
+		a.refreshCurrent(ce.Note)
+		if markers {
+			// Let the user resolve the conflict markers by hand
+			return a, a.cmdOpenEditor(ce.Note)
+		}
+		a.setStatus("merged "+ce.Note.Title, false)
+		return a, a.cmdLoadNotes()
+
+	case "esc", "q":
+		a.resolveConflict()
+		a.setStatus("not saved — "+ce.Note.Title+" changed on disk", true)
+	}
+	return a, nil
+}
+
+func (a *App) resolveConflict() {
+	a.state = a.conflictReturn
+	a.conflict = nil
+}
+
+// refreshCurrent swaps in n if it is the note open in the viewer.
+func (a *App) refreshCurrent(n *notes.Note) {
+	if a.current != nil && a.current.ID == n.ID {
+		a.current = n
+		a.reRender()
+	}
+}
+
+// ── Vault AI ──────────────────────────────────────────────────────────────────
+
+func (a *App) updateVaultAI(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if a.aiSaving {
+		return a, a.updateSaveAnswer(msg, a.vaultAIHistory, a.selectedNote())
+	}
+	switch msg.String() {
+	case "ctrl+s":
+		a.startSaveAnswer(a.vaultAIHistory, a.vaultAILoading)
+		return a, nil
+
+	case "esc":
+		if a.vaultAILoading {
+			a.vaultAICancel()
+			a.vaultAILoading = false
+			if len(a.vaultAIHistory) > 0 {
+				a.vaultAIHistory[len(a.vaultAIHistory)-1].cancelled = true
+			}
+			return a, nil
+		}
+		a.state = stateList
+		a.vaultAIInput.Blur()
+		return a, nil
+
+	case "enter":
 		q := strings.TrimSpace(a.vaultAIInput.Value())
 		if q == "" || a.vaultAILoading {
 			return a, nil
@@ -820,6 +2001,20 @@ func (a *App) View() string {
 		return a.viewLinks()
 	case stateVaultAI:
 		return a.viewVaultAI()
+	case stateConflict:
+		return a.viewConflict()
+	case stateRename:
+		return a.viewRename()
+	case stateRenamePreview:
+		return a.viewRenamePreview()
+	case stateDoctor:
+		return a.viewDoctor()
+	case stateCreateLink:
+		return a.viewCreateLink()
+	case stateGraph:
+		return a.viewGraph()
+	case stateTags:
+		return a.viewTags()
 	}
 	return ""
 }
@@ -829,6 +2024,12 @@ func (a *App) viewList() string {
 	w := a.width
 
 	count := fmt.Sprintf("%d notes", len(a.allNotes))
+	if a.activeSaved != "" {
+		count = fmt.Sprintf("@%s  ·  %d of %d notes", a.activeSaved, len(a.filtered), len(a.allNotes))
+	}
+	if a.treeMode {
+		count += "  ·  folders"
+	}
 	b.WriteString(styleTitle.Render("grove") + styleDivider.Render("  —  ") + styleSubtitle.Render(count) + "\n")
 	b.WriteString(styleDivider.Render(strings.Repeat("─", w)) + "\n")
 
@@ -838,46 +2039,73 @@ func (a *App) viewList() string {
 		listH = 1
 	}
 
-	if len(a.filtered) == 0 {
-		empty := styleSubtitle.Render("\n  no notes — press n to create one, t for today's daily note")
-		b.WriteString(empty + "\n")
+	sidebar := ""
+	if a.showSidebar() {
+		sidebar = a.viewSidebar(listH)
+		w -= sidebarWidth
+	}
+
+	var lines []string
+	if a.listLen() == 0 {
+		msg := "  no notes — press n to create one, t for today's daily note"
+		if a.activeSaved != "" {
+			msg = "  nothing matches @" + a.activeSaved + " — Esc to show all notes"
+		}
+		lines = append(lines, "", styleSubtitle.Render(msg))
 	} else {
-		end := min(a.listOffset+listH, len(a.filtered))
+		end := min(a.listOffset+listH, a.listLen())
 		for i := a.listOffset; i < end; i++ {
-			n := a.filtered[i]
+			var n *notes.Note
+			indent := ""
+			if a.treeMode {
+				row := a.rows[i]
+				indent = strings.Repeat("  ", row.depth)
+				if row.note == nil {
+					lines = append(lines, a.viewFolderRow(row, indent, i == a.cursor && !a.sidebarFocus, w))
+					continue
+				}
+				n = row.note
+			} else {
+				n = a.filtered[i]
+			}
 			age := humanTime(n.Updated)
-			maxTitle := w - len(age) - 6
+			maxTitle := w - len(age) - 6 - len(indent)
 			if maxTitle < 10 {
 				maxTitle = 10
 			}
 			title := truncate(n.Title, maxTitle)
-			pad := w - 4 - len([]rune(title)) - len(age)
+			pad := w - 4 - len(indent) - len([]rune(title)) - len(age)
 			if pad < 1 {
 				pad = 1
 			}
 			spacer := strings.Repeat(" ", pad)
 
-			if i == a.cursor {
-				b.WriteString("  " + styleSelectedItem.Render("▸ "+title) + spacer + styleDimItem.Render(age) + "\n")
+			if i == a.cursor && !a.sidebarFocus {
+				lines = append(lines, "  "+indent+styleSelectedItem.Render("▸ "+title)+spacer+styleDimItem.Render(age))
 			} else {
-				b.WriteString("    " + styleNormalItem.Render(title) + spacer + styleDimItem.Render(age) + "\n")
+				lines = append(lines, "    "+indent+styleNormalItem.Render(title)+spacer+styleDimItem.Render(age))
 			}
 		}
 	}
 
 	// Pad to fill height
-	shown := min(listH, len(a.filtered))
-	for i := shown; i < listH; i++ {
-		b.WriteString("\n")
+	for len(lines) < listH {
+		lines = append(lines, "")
+	}
+	body := strings.Join(lines, "\n")
+	if sidebar != "" {
+		body = lipgloss.JoinHorizontal(lipgloss.Top, sidebar, body)
 	}
+	b.WriteString(body + "\n")
+	w = a.width
 
 	// Note preview: first non-empty non-heading line of highlighted note
 	preview := ""
-	if len(a.filtered) > 0 && a.cursor < len(a.filtered) {
-		preview = notePreview(a.filtered[a.cursor].Body, w-4)
+	if n := a.selectedNote(); n != nil {
+		preview = notePreview(n.Body, w-4)
 	}
 	if preview != "" {
-		b.WriteString(styleDimItem.Render("  " + preview) + "\n")
+		b.WriteString(styleDimItem.Render("  "+preview) + "\n")
 	} else {
 		b.WriteString("\n")
 	}
@@ -891,12 +2119,70 @@ func (a *App) viewList() string {
 		}
 		b.WriteString(sty.Render("  " + a.statusMsg))
 	} else {
-		b.WriteString(styleHint.Render("  j/k · Enter · n/N new · t daily · / search · d del · @ AI · ? help · q"))
+		hint := "  j/k · Enter · n/N new · t daily · / search · f folders · d del · @ AI · ? help · q"
+		if a.sidebarFocus {
+			hint = "  j/k · Enter apply saved search · Tab/Esc back to notes"
+		} else if a.activeSaved != "" {
+			hint = "  j/k · Enter · Tab saved searches · Esc show all notes · / search · ? help · q"
+		}
+		b.WriteString(styleHint.Render(hint))
 	}
 
 	return b.String()
 }
 
+// sidebarWidth is the width of the saved searches column, including its border.
+const sidebarWidth = 26
+
+func (a *App) showSidebar() bool {
+	return len(a.cfg.SavedSearches) > 0 && a.width >= 70
+}
+
+func (a *App) viewSidebar(h int) string {
+	lines := []string{styleAILabel.Render(" saved searches")}
+	for i, s := range a.cfg.SavedSearches {
+		count := ""
+		if i < len(a.savedCounts) {
+			count = fmt.Sprintf("%d", a.savedCounts[i])
+		}
+		name := truncate("@"+s.Name, sidebarWidth-6-len(count))
+		pad := max(1, sidebarWidth-5-len([]rune(name))-len(count))
+		switch {
+		case a.sidebarFocus && i == a.sidebarCursor:
+			lines = append(lines, " "+styleSelectedItem.Render("▸ "+name)+strings.Repeat(" ", pad-1)+styleDimItem.Render(count))
+		case s.Name == a.activeSaved:
+			lines = append(lines, "  "+styleTag.Render(name)+strings.Repeat(" ", pad)+styleDimItem.Render(count))
+		default:
+			lines = append(lines, "  "+styleNormalItem.Render(name)+strings.Repeat(" ", pad)+styleDimItem.Render(count))
+		}
+	}
+	for len(lines) < h {
+		lines = append(lines, "")
+	}
+	return lipgloss.NewStyle().
+		Width(sidebarWidth-1).
+		Border(lipgloss.NormalBorder(), false, true, false, false).
+		BorderForeground(colorDim).
+		Render(strings.Join(lines[:h], "\n"))
+}
+
+func (a *App) viewFolderRow(row listRow, indent string, selected bool, w int) string {
+	icon := "▾ "
+	if a.collapsed[row.folder] {
+		icon = "▸ "
+	}
+	count := fmt.Sprintf("%d", row.count)
+	name := truncate(row.name+"/", w-len(count)-8-len(indent))
+	pad := w - 6 - len(indent) - len([]rune(name)) - len(count)
+	if pad < 1 {
+		pad = 1
+	}
+	if selected {
+		return "  " + indent + styleSelectedItem.Render("▸ "+icon+name) + strings.Repeat(" ", pad) + styleDimItem.Render(count)
+	}
+	return "    " + indent + styleTag.Render(icon+name) + strings.Repeat(" ", pad) + styleDimItem.Render(count)
+}
+
 func (a *App) viewViewer() string {
 	if a.current == nil {
 		return "no note"
@@ -927,7 +2213,7 @@ func (a *App) viewViewer() string {
 				break
 			}
 		}
-		b.WriteString(styleHint.Render(fmt.Sprintf("  j/k  gg/G  {/}  d/u  e edit  A AI  L links  q back%s  %d words  %d%%", pos, wc, pct)))
+		b.WriteString(styleHint.Render(fmt.Sprintf("  j/k  gg/G  {/}  d/u  e edit  A AI  L links  R rename  q back%s  %d words  %d%%", pos, wc, pct)))
 	}
 	return b.String()
 }
@@ -940,7 +2226,8 @@ func (a *App) viewSearch() string {
 	b.WriteString(styleDivider.Render(strings.Repeat("─", w)) + "\n")
 	b.WriteString(styleInputActive.Width(w-4).Render(a.searchInput.View()) + "\n")
 
-	listH := a.height - 7
+	// Reserve 1 extra line for the match snippet
+	listH := a.height - 8
 	if listH < 1 {
 		listH = 1
 	}
@@ -974,14 +2261,31 @@ func (a *App) viewSearch() string {
 		b.WriteString("\n")
 	}
 
+	// Where the highlighted result matched, or why the query doesn't parse
+	if a.searchErr != "" {
+		b.WriteString(styleError.Render("  "+truncate(a.searchErr, w-4)) + "\n")
+	} else {
+		snippet := ""
+		if a.cursor < len(a.searchResults) {
+			if sn := a.searchResults[a.cursor].Snippets; len(sn) > 0 {
+				snippet = truncate(fmt.Sprintf("%d: %s", sn[0].Line, sn[0].Text), w-4)
+			}
+		}
+		b.WriteString(styleDimItem.Render("  "+snippet) + "\n")
+	}
+
 	b.WriteString(styleDivider.Render(strings.Repeat("─", w)) + "\n")
-	b.WriteString(styleHint.Render("  type to search  Enter open  ctrl+n/p navigate  Esc cancel"))
+	b.WriteString(styleHint.Render("  \"phrase\" prefix* tag: title: links: has:todo updated:>7d -x OR  ·  Enter open  ctrl+n/p  Esc"))
 	return b.String()
 }
 
 func (a *App) viewNewNote() string {
 	var b strings.Builder
-	b.WriteString(styleTitle.Render("grove") + styleDivider.Render("  +  ") + styleSubtitle.Render("new note") + "\n")
+	label := "new note"
+	if a.newNoteFolder != "" {
+		label += " in " + a.newNoteFolder + "/"
+	}
+	b.WriteString(styleTitle.Render("grove") + styleDivider.Render("  +  ") + styleSubtitle.Render(label) + "\n")
 	b.WriteString(styleDivider.Render(strings.Repeat("─", a.width)) + "\n\n")
 	b.WriteString(styleHint.Render("  Note title:") + "\n")
 	b.WriteString(styleInputActive.Width(a.width-4).Render(a.newNoteInput.View()) + "\n\n")
@@ -1040,7 +2344,7 @@ func (a *App) viewAIPanel() string {
 		lines = []string{styleSubtitle.Render("  Ask anything about this note...")}
 	} else {
 		for _, entry := range a.aiHistory {
-			lines = append(lines, styleAILabel.Render("  Q: ")+styleNormalItem.Render(entry.question))
+			lines = append(lines, styleAILabel.Render("  Q: ")+styleNormalItem.Render(entry.question)+askedAt(entry))
 			if entry.answer != "" {
 				rendered := entry.answer
 				if r != nil {
@@ -1052,9 +2356,12 @@ func (a *App) viewAIPanel() string {
 					lines = append(lines, l)
 				}
 			}
+			if entry.cancelled {
+				lines = append(lines, styleDimItem.Render("  (cancelled)"))
+			}
 			lines = append(lines, "")
 		}
-		if a.aiLoading {
+		if a.aiLoading && a.aiHistory[len(a.aiHistory)-1].answer == "" {
 			lines = append(lines, styleSubtitle.Render("  thinking..."))
 		}
 		if a.aiError != "" {
@@ -1078,9 +2385,9 @@ func (a *App) viewAIPanel() string {
 	b.WriteString(styleDivider.Render(strings.Repeat("─", w)) + "\n")
 
 	if a.aiLoading {
-		b.WriteString(styleHint.Render("  waiting for Gemini..."))
+		b.WriteString(styleHint.Render("  waiting for " + a.ai.Name() + "...  Esc cancel"))
 	} else {
-		b.WriteString(styleHint.Render("  Enter submit  Esc back to note"))
+		b.WriteString(a.aiPanelHint(a.current, "  Enter submit  ctrl+s save answer  Esc back to note"))
 	}
 	return b.String()
 }
@@ -1097,6 +2404,146 @@ func (a *App) viewConfirmDelete() string {
 	return b.String()
 }
 
+func (a *App) viewDoctor() string {
+	var b strings.Builder
+	w := a.width
+	summary := "no problems"
+	if len(a.problems) > 0 {
+		summary = fmt.Sprintf("%d problems", len(a.problems))
+	}
+	b.WriteString(styleTitle.Render("grove") + styleDivider.Render("  —  ") + styleSubtitle.Render("doctor · "+summary) + "\n")
+	b.WriteString(styleDivider.Render(strings.Repeat("─", w)) + "\n")
+
+	var lines []string
+	cursorLine := 0
+	for i, p := range a.problems {
+		if i == 0 || p.Kind != a.problems[i-1].Kind {
+			if i > 0 {
+				lines = append(lines, "")
+			}
+			lines = append(lines, styleAILabel.Render("  "+p.Kind.String()))
+		}
+		detail := p.Detail
+		if p.Kind == notes.BrokenLink {
+			detail = "[[" + p.Link.String() + "]]  " + detail
+		}
+		title := truncate(p.Note.Title, 30)
+		text := truncate(title+"  ·  "+detail, w-8)
+		if i == a.doctorCursor {
+			cursorLine = len(lines)
+			lines = append(lines, "  "+styleSelectedItem.Render("▸ "+text))
+		} else {
+			lines = append(lines, "    "+styleNormalItem.Render(title)+styleDimItem.Render(truncate("  ·  "+detail, w-8-len([]rune(title)))))
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "", styleSubtitle.Render("  no broken links, orphans, duplicates or bad frontmatter — the garden is healthy"))
+	}
+
+	listH := max(1, a.height-5)
+	start := 0
+	if cursorLine >= listH {
+		start = cursorLine - listH + 1
+	}
+	lines = lines[start:min(len(lines), start+listH)]
+	for len(lines) < listH {
+		lines = append(lines, "")
+	}
+	b.WriteString(strings.Join(lines, "\n") + "\n")
+
+	b.WriteString(styleDivider.Render(strings.Repeat("─", w)) + "\n")
+	hint := "  j/k navigate  Enter open note  Esc back"
+	if n := len(notes.Dangling(a.problems)); n > 0 {
+		hint = fmt.Sprintf("  j/k navigate  Enter open note  s stub missing notes (%d)  Esc back", n)
+	}
+	b.WriteString(styleHint.Render(hint))
+	return b.String()
+}
+
+func (a *App) viewCreateLink() string {
+	var b strings.Builder
+	l := a.createLink
+	b.WriteString(styleTitle.Render("grove") + styleDivider.Render("  +  ") + styleSubtitle.Render("new note from link") + "\n")
+	b.WriteString(styleDivider.Render(strings.Repeat("─", a.width)) + "\n\n")
+	b.WriteString(styleConfirm.Render(fmt.Sprintf("  [[%s]] doesn't exist yet. Create it?", l.Target)) + "\n")
+	if a.current != nil && a.current.Folder != "" {
+		b.WriteString(styleDimItem.Render("  in "+a.current.Folder+"/") + "\n")
+	}
+	b.WriteString("\n" + styleHint.Render("  Template:") + "\n")
+	for i, name := range templates.Names {
+		if i == a.createCursor {
+			b.WriteString("  " + styleSelectedItem.Render("▸ "+name) + "\n")
+		} else {
+			b.WriteString("    " + styleNormalItem.Render(name) + "\n")
+		}
+	}
+	b.WriteString("\n")
+	b.WriteString(styleDivider.Render(strings.Repeat("─", a.width)) + "\n")
+	b.WriteString(styleHint.Render("  j/k template  Enter create and open in $EDITOR  Esc cancel"))
+	return b.String()
+}
+
+func (a *App) viewRename() string {
+	var b strings.Builder
+	b.WriteString(styleTitle.Render("grove") + styleDivider.Render("  —  ") + styleSubtitle.Render("rename "+a.renameTarget.Title) + "\n")
+	b.WriteString(styleDivider.Render(strings.Repeat("─", a.width)) + "\n\n")
+	b.WriteString(styleHint.Render("  New title:") + "\n")
+	b.WriteString(styleInputActive.Width(a.width-4).Render(a.renameInput.View()) + "\n\n")
+	b.WriteString(styleHint.Render("  Enter to preview changes  ·  Esc to cancel"))
+	return b.String()
+}
+
+func (a *App) viewRenamePreview() string {
+	p := a.renamePlan
+	if p == nil {
+		return a.viewRename()
+	}
+	var b strings.Builder
+	b.WriteString(styleTitle.Render("grove") + styleDivider.Render("  —  ") + styleSubtitle.Render("rename preview") + "\n")
+	b.WriteString(styleDivider.Render(strings.Repeat("─", a.width)) + "\n\n")
+	b.WriteString(styleConfirm.Render(fmt.Sprintf("  \"%s\" → \"%s\"", p.OldTitle, p.NewTitle)) + "\n")
+	if p.NewID != p.OldID {
+		b.WriteString(styleDimItem.Render(fmt.Sprintf("  %s.md → %s.md", p.OldID, p.NewID)) + "\n")
+	}
+	b.WriteString("\n")
+
+	if len(p.Edits) == 0 {
+		b.WriteString(styleDimItem.Render("  no other notes link to it") + "\n")
+	} else {
+		b.WriteString(styleAILabel.Render(fmt.Sprintf("  %d links in %d notes will be rewritten", p.Links(), len(p.Edits))) + "\n")
+		// Leave room for the header and the key hints
+		room := max(1, a.height-11)
+		for i, e := range p.Edits {
+			if i == room-1 && len(p.Edits) > room {
+				b.WriteString(styleDimItem.Render(fmt.Sprintf("    … and %d more", len(p.Edits)-i)) + "\n")
+				break
+			}
+			b.WriteString("    " + styleNormalItem.Render(truncate(e.Note.Title, a.width-16)) + styleDimItem.Render(fmt.Sprintf("  ×%d", e.Count)) + "\n")
+		}
+	}
+
+	b.WriteString("\n")
+	b.WriteString(styleNormalItem.Render("  y") + styleHint.Render(" rename   ") + styleNormalItem.Render("n / Esc") + styleHint.Render(" back") + "\n")
+	return b.String()
+}
+
+func (a *App) viewConflict() string {
+	ce := a.conflict
+	if ce == nil {
+		return a.viewList()
+	}
+	var b strings.Builder
+	b.WriteString(styleTitle.Render("grove") + styleDivider.Render("  —  ") + styleSubtitle.Render("save conflict") + "\n")
+	b.WriteString(styleDivider.Render(strings.Repeat("─", a.width)) + "\n\n")
+	b.WriteString(styleConfirm.Render(fmt.Sprintf("  \"%s\" was changed on disk since grove loaded it.", ce.Note.Title)) + "\n\n")
+	b.WriteString(styleDimItem.Render(fmt.Sprintf("  disk version updated %s, %d words", humanTime(ce.Disk.Updated), wordCount(ce.Disk.Body))) + "\n\n")
+	b.WriteString(styleNormalItem.Render("  m") + styleHint.Render(" merge (opens $EDITOR if both sides changed the text)") + "\n")
+	b.WriteString(styleNormalItem.Render("  o") + styleHint.Render(" overwrite with my version") + "\n")
+	b.WriteString(styleNormalItem.Render("  r") + styleHint.Render(" reload from disk, discard my changes") + "\n")
+	b.WriteString(styleNormalItem.Render("  Esc") + styleHint.Render(" cancel, don't save") + "\n")
+	return b.String()
+}
+
 func (a *App) viewHelp() string {
 	help := lipgloss.JoinVertical(lipgloss.Left,
 		styleDivider.Render("  LIST"),
@@ -1106,8 +2553,15 @@ func (a *App) viewHelp() string {
 		"    n            new note",
 		"    N            new note with template",
 		"    t            today's daily note",
-		"    /            fuzzy search",
+		"    /            search (\"phrase\", prefix*)",
+		"    f            toggle folder tree",
+		"    Enter / h    expand / collapse folder (tree)",
+		"    Tab          saved searches sidebar",
+		"    Esc          clear saved search filter",
 		"    d            delete (with confirm)",
+		"    R            rename (previews link updates)",
+		"    D            doctor: broken links, orphans…",
+		"    T            AI tags for untagged notes",
 		"    @            vault-wide AI",
 		"    r            refresh",
 		"    q            quit",
@@ -1120,28 +2574,52 @@ func (a *App) viewHelp() string {
 		"    e            open in $EDITOR",
 		"    A            ask AI about note",
 		"    L            links panel (wiki-links)",
+		"    R            rename note",
+		"    M            link map: neighborhood graph",
+		"    T            AI tag suggestions",
 		"    q / h / Esc  back to list",
 		"",
 		styleDivider.Render("  SEARCH"),
-		"    type         filter",
+		"    type         filter: words, \"phrase\", prefix*",
+		"                 tag:work -tag:archive title:\"kickoff\"",
+		"                 links:Roadmap has:todo updated:>2026-09-01",
+		"                 folder:projects created:<=7d  a OR b  (…)",
+		"                 @name runs a saved search",
 		"    Enter        open",
 		"    ctrl+n/p     navigate results",
 		"    Esc          cancel",
 		"",
 		styleDivider.Render("  AI PANEL"),
 		"    type         your question",
-		"    Enter        send to Gemini",
-		"    Esc          back",
+		"    Enter        send to the AI provider",
+		"    ctrl+s       save the last answer: a append to the note under",
+		"                 ## AI, n new note, t today's daily note",
+		"    Esc          cancel the answer / back",
 		"",
 		styleDivider.Render("  LINKS PANEL"),
 		"    j/k          navigate",
 		"    Enter        open linked note",
+		"    m            turn a mention into a wiki-link",
 		"    Esc / q      back to viewer",
 		"",
+		styleDivider.Render("  LINK MAP  (M)"),
+		"    j/k          navigate",
+		"    + / -        more / fewer hops",
+		"    c / Space    re-center on selected note",
+		"    Enter        open note",
+		"    Esc / q      back to viewer",
+		"",
+		styleDivider.Render("  TAG SUGGESTIONS  (T)"),
+		"    j/k          navigate",
+		"    Space / x    tick or untick a tag",
+		"    Enter        add the ticked tags",
+		"    Esc / q      cancel",
+		"",
 		styleDivider.Render("  VAULT AI  (@)"),
 		"    type         your question",
-		"    Enter        send to Gemini",
-		"    Esc          back to list",
+		"    Enter        send to the AI provider",
+		"    ctrl+s       save the last answer (a appends to the selected note)",
+		"    Esc          cancel the answer / back to list",
 	)
 
 	var b strings.Builder
@@ -1161,60 +2639,212 @@ func (a *App) viewLinks() string {
 	w := a.width
 
 	b.WriteString(styleTitle.Render("grove") + styleDivider.Render("  —  ") + styleSubtitle.Render("links: "+a.current.Title) + "\n")
-	b.WriteString(styleDivider.Render(strings.Repeat("─", w)) + "\n\n")
+	b.WriteString(styleDivider.Render(strings.Repeat("─", w)) + "\n")
 
+	lines := []string{""}
 	idx := 0
+	cursorLine := 0
+	entry := func(selected, normal string) {
+		if idx == a.linksCursor {
+			cursorLine = len(lines)
+			lines = append(lines, "  "+styleSelectedItem.Render("▸ "+selected))
+		} else {
+			lines = append(lines, "    "+normal)
+		}
+		idx++
+	}
 
 	// Outgoing links
-	b.WriteString(styleAILabel.Render("  → outgoing links") + "\n")
+	lines = append(lines, styleAILabel.Render("  → outgoing links"))
 	if len(a.linksOut) == 0 {
-		b.WriteString(styleDimItem.Render("    (none)") + "\n")
-	} else {
-		for _, target := range a.linksOut {
-			// Check if note exists
-			found := false
-			for _, n := range a.allNotes {
-				if strings.EqualFold(n.Title, target) {
-					found = true
-					break
-				}
-			}
-			label := "[[" + target + "]]"
-			if !found {
-				label = label + " (not found)"
-			}
-			if idx == a.linksCursor {
-				b.WriteString("  " + styleSelectedItem.Render("▸ "+label) + "\n")
-			} else if !found {
-				b.WriteString("    " + styleDimItem.Render(label) + "\n")
-			} else {
-				b.WriteString("    " + styleNormalItem.Render(label) + "\n")
-			}
-			idx++
+		lines = append(lines, styleDimItem.Render("    (none)"))
+	}
+	for _, link := range a.linksOut {
+		// Check if note exists
+		n := a.current
+		if link.Target != "" {
+			n = a.resolve(link.Target)
+		}
+		label := "[[" + link.String() + "]]"
+		if n == nil {
+			label = label + " (not found — Enter to create)"
+			entry(label, styleDimItem.Render(label))
+			continue
 		}
+		if !strings.EqualFold(n.Title, link.Target) {
+			label += " → " + n.Title
+		}
+		entry(label, styleNormalItem.Render(label))
 	}
 
-	b.WriteString("\n")
-
 	// Backlinks
-	b.WriteString(styleAILabel.Render("  ← backlinks") + "\n")
+	lines = append(lines, "", styleAILabel.Render("  ← backlinks"))
 	if len(a.linksBack) == 0 {
-		b.WriteString(styleDimItem.Render("    (none)") + "\n")
-	} else {
-		for _, n := range a.linksBack {
-			label := n.Title
-			if idx == a.linksCursor {
-				b.WriteString("  " + styleSelectedItem.Render("▸ "+label) + "\n")
+		lines = append(lines, styleDimItem.Render("    (none)"))
+	}
+	for _, n := range a.linksBack {
+		entry(n.Title, styleNormalItem.Render(n.Title))
+	}
+
+	// Unlinked mentions
+	lines = append(lines, "", styleAILabel.Render("  ~ unlinked mentions"))
+	if len(a.linksMentions) == 0 {
+		lines = append(lines, styleDimItem.Render("    (none)"))
+	}
+	for _, m := range a.linksMentions {
+		title := truncate(m.Note.Title, 30)
+		context := truncate("  ·  "+m.Context, max(10, w-8-len([]rune(title))))
+		entry(title+context, styleNormalItem.Render(title)+styleDimItem.Render(context))
+	}
+
+	listH := max(1, a.height-4)
+	start := 0
+	if cursorLine >= listH {
+		start = cursorLine - listH + 1
+	}
+	lines = lines[start:min(len(lines), start+listH)]
+	for len(lines) < listH {
+		lines = append(lines, "")
+	}
+	b.WriteString(strings.Join(lines, "\n") + "\n")
+
+	b.WriteString(styleDivider.Render(strings.Repeat("─", w)) + "\n")
+	hint := "  j/k navigate  Enter open  Esc back to viewer"
+	if a.linksCursor >= len(a.linksOut)+len(a.linksBack) && len(a.linksMentions) > 0 {
+		hint = "  j/k navigate  Enter open  m link this mention  Esc back to viewer"
+	}
+	b.WriteString(styleHint.Render(hint))
+	return b.String()
+}
+
+func (a *App) viewGraph() string {
+	var b strings.Builder
+	w := a.width
+	title := "link map"
+	if a.graphCenter != nil {
+		title = fmt.Sprintf("link map: %s · %d hop", a.graphCenter.Title, a.graphHops)
+		if a.graphHops > 1 {
+			title += "s"
+		}
+	}
+	b.WriteString(styleTitle.Render("grove") + styleDivider.Render("  —  ") + styleSubtitle.Render(title) + "\n")
+	b.WriteString(styleDivider.Render(strings.Repeat("─", w)) + "\n")
+
+	var lines []string
+	for i, r := range a.graphRows {
+		in, out := a.graph.Degree(r.note)
+		degree := fmt.Sprintf("  %d in · %d out", in, out)
+		label := r.note.Title
+		if r.depth > 0 {
+			label = r.arrow + " " + label
+		}
+		branch := styleDivider.Render(r.prefix)
+		text := truncate(label, max(10, w-8-len([]rune(r.prefix))-len(degree)))
+		switch {
+		case i == a.graphCursor:
+			lines = append(lines, "  "+branch+styleSelectedItem.Render(text)+styleDimItem.Render(degree))
+		case r.depth == 0:
+			lines = append(lines, "  "+styleAILabel.Render(text)+styleDimItem.Render(degree))
+		default:
+			lines = append(lines, "  "+branch+styleNormalItem.Render(text)+styleDimItem.Render(degree))
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "", styleSubtitle.Render("  note not found — it may have been removed"))
+	} else if len(lines) == 1 {
+		lines = append(lines, "", styleSubtitle.Render("  no links to or from this note yet"))
+	}
+
+	listH := max(1, a.height-5)
+	start := 0
+	if a.graphCursor >= listH {
+		start = a.graphCursor - listH + 1
+	}
+	lines = lines[start:min(len(lines), start+listH)]
+	for len(lines) < listH {
+		lines = append(lines, "")
+	}
+	b.WriteString(strings.Join(lines, "\n") + "\n")
+
+	b.WriteString(styleDivider.Render(strings.Repeat("─", w)) + "\n")
+	b.WriteString(styleHint.Render(fmt.Sprintf("  j/k navigate  Enter open  c re-center  +/- hops  Esc back   %d notes", len(a.graphRows))))
+	return b.String()
+}
+
+func (a *App) viewTags() string {
+	var b strings.Builder
+	w := a.width
+	title := "tag suggestions"
+	if len(a.tagReviews) > 1 {
+		title = fmt.Sprintf("tag suggestions: %d untagged notes", len(a.tagReviews))
+	}
+	if a.tagMore > 0 {
+		title += fmt.Sprintf(" (%d more after these; press T again)", a.tagMore)
+	}
+	b.WriteString(styleTitle.Render("grove") + styleDivider.Render("  —  ") + styleSubtitle.Render(title) + "\n")
+	b.WriteString(styleDivider.Render(strings.Repeat("─", w)) + "\n")
+
+	uses := map[string]int{}
+	for _, tc := range a.tagVocab {
+		uses[tc.Tag] = tc.Notes
+	}
+	var lines []string
+	cursorLine, row, pending := 0, 0, 0
+	for _, r := range a.tagReviews {
+		head := "  " + styleNormalItem.Render(truncate(r.note.Title, w-30))
+		if len(r.note.Tags) > 0 {
+			head += styleTag.Render("  #" + strings.Join(r.note.Tags, " #"))
+		}
+		lines = append(lines, head)
+		switch {
+		case !r.done:
+			pending++
+			lines = append(lines, styleDimItem.Render("    waiting for "+a.ai.Name()+"..."))
+		case r.err != "":
+			lines = append(lines, styleError.Render("    error: "+r.err))
+		case len(r.suggestions) == 0:
+			lines = append(lines, styleDimItem.Render("    no suggestions"))
+		}
+		for j, sg := range r.suggestions {
+			box := "[ ] "
+			if r.chosen[j] {
+				box = "[x] "
+			}
+			detail := "  new tag"
+			if sg.Known {
+				detail = fmt.Sprintf("  %d notes", uses[sg.Tag])
+				if uses[sg.Tag] == 1 {
+					detail = "  1 note"
+				}
+			}
+			if row == a.tagCursor {
+				cursorLine = len(lines)
+				lines = append(lines, "    "+styleSelectedItem.Render(box+sg.Tag)+styleDimItem.Render(detail))
 			} else {
-				b.WriteString("    " + styleNormalItem.Render(label) + "\n")
+				lines = append(lines, "    "+styleNormalItem.Render(box+sg.Tag)+styleDimItem.Render(detail))
 			}
-			idx++
+			row++
 		}
+		lines = append(lines, "")
 	}
 
-	b.WriteString("\n")
+	listH := max(1, a.height-5)
+	start := 0
+	if cursorLine >= listH {
+		start = cursorLine - listH + 1
+	}
+	lines = lines[start:min(len(lines), start+listH)]
+	for len(lines) < listH {
+		lines = append(lines, "")
+	}
+	b.WriteString(strings.Join(lines, "\n") + "\n")
+
 	b.WriteString(styleDivider.Render(strings.Repeat("─", w)) + "\n")
-	b.WriteString(styleHint.Render("  j/k navigate  Enter open  Esc back to viewer"))
+	hint := "  j/k navigate  Space toggle  Enter add ticked tags  Esc cancel"
+	if pending > 0 {
+		hint += fmt.Sprintf("   (%d to go)", pending)
+	}
+	b.WriteString(styleHint.Render(hint))
 	return b.String()
 }
 
@@ -1238,7 +2868,7 @@ func (a *App) viewVaultAI() string {
 		lines = []string{styleSubtitle.Render("  Ask anything about your vault...")}
 	} else {
 		for _, entry := range a.vaultAIHistory {
-			lines = append(lines, styleAILabel.Render("  Q: ")+styleNormalItem.Render(entry.question))
+			lines = append(lines, styleAILabel.Render("  Q: ")+styleNormalItem.Render(entry.question)+askedAt(entry))
 			if entry.answer != "" {
 				rendered := entry.answer
 				if r != nil {
@@ -1250,9 +2880,15 @@ func (a *App) viewVaultAI() string {
 					lines = append(lines, l)
 				}
 			}
+			for _, c := range entry.sources {
+				lines = append(lines, styleDimItem.Render(truncate("  "+c.String(), w-10)))
+			}
+			if entry.cancelled {
+				lines = append(lines, styleDimItem.Render("  (cancelled)"))
+			}
 			lines = append(lines, "")
 		}
-		if a.vaultAILoading {
+		if a.vaultAILoading && a.vaultAIHistory[len(a.vaultAIHistory)-1].answer == "" {
 			lines = append(lines, styleSubtitle.Render("  thinking..."))
 		}
 		if a.vaultAIError != "" {
@@ -1276,9 +2912,9 @@ func (a *App) viewVaultAI() string {
 	b.WriteString(styleDivider.Render(strings.Repeat("─", w)) + "\n")
 
 	if a.vaultAILoading {
-		b.WriteString(styleHint.Render(fmt.Sprintf("  waiting for Gemini...  (%d notes in context)", len(a.allNotes))))
+		b.WriteString(styleHint.Render(fmt.Sprintf("  waiting for %s...  Esc cancel  (searching %d notes)", a.ai.Name(), len(a.allNotes))))
 	} else {
-		b.WriteString(styleHint.Render(fmt.Sprintf("  Enter submit  Esc back  (%d notes)", len(a.allNotes))))
+		b.WriteString(a.aiPanelHint(a.selectedNote(), fmt.Sprintf("  Enter submit  ctrl+s save answer  Esc back  (%d notes)", len(a.allNotes))))
 	}
 	return b.String()
 }
@@ -1292,7 +2928,7 @@ func (a *App) openNote(note *notes.Note) {
 		return
 	}
 	a.current = loaded
-	a.aiHistory = nil
+	a.aiHistory = a.loggedEntries(loaded.ID)
 	a.state = stateViewer
 	a.reRender()
 }
@@ -1302,9 +2938,16 @@ func (a *App) reRender() {
 		return
 	}
 
-	// Preprocess wiki-links: replace [[target]] with `[[target]]` so glamour
-	// renders them as inline code — visually distinct without breaking layout.
-	body := preprocessLinks(a.current.Body)
+	// Expand ![[embeds]] first, so links inside them are styled too
+	body := a.current.Body
+	if a.resolver != nil {
+		body = notes.ExpandEmbeds(a.current, a.resolver, renderEmbed)
+	}
+
+	// Preprocess wiki-links: replace [[target]] with `[[target]]` (or just
+	// `label` for [[target|label]]) so glamour renders them as inline code —
+	// visually distinct without breaking layout.
+	body = preprocessLinks(body)
 
 	r, err := glamour.NewTermRenderer(
 		glamour.WithAutoStyle(),
@@ -1328,6 +2971,184 @@ func (a *App) reRender() {
 	a.viewport.GotoTop()
 }
 
+// applyNotes swaps in a freshly loaded set of notes, keeping the list cursor
+// on the same note, the active search applied, and the open note current.
+func (a *App) applyNotes(ns []*notes.Note) {
+	a.allNotes = ns
+	a.searchIdx = nil
+	a.resolver = notes.NewResolver(ns)
+	a.graph = nil
+	a.warnAliasConflicts()
+	if a.state == stateGraph {
+		a.refreshGraph()
+	}
+	if a.state == stateLinks && a.current != nil {
+		a.refreshLinks()
+	}
+	if a.state == stateDoctor {
+		a.problems = notes.Check(ns)
+		a.doctorCursor = min(a.doctorCursor, max(0, len(a.problems)-1))
+	}
+	if a.state == stateSearch {
+		// The cursor indexes search results here, not list rows
+		a.runSearch(a.searchQuery)
+		if a.cursor >= len(a.filtered) {
+			a.cursor = max(0, len(a.filtered)-1)
+		}
+	} else {
+		selectedID := ""
+		if n := a.selectedNote(); n != nil {
+			selectedID = n.ID
+		}
+		a.filtered = a.listNotes()
+		a.refreshRows()
+		a.moveCursorTo(selectedID)
+	}
+	if len(a.cfg.SavedSearches) > 0 {
+		a.countSaved()
+	}
+
+	if a.current == nil {
+		return
+	}
+	for _, n := range ns {
+		if n.ID == a.current.ID {
+			if n.Raw != a.current.Raw {
+				offset := a.viewport.YOffset
+				a.current = n
+				a.reRender()
+				a.viewport.SetYOffset(offset)
+			}
+			return
+		}
+	}
+	if a.state == stateViewer {
+		a.setStatus(a.current.Title+" was removed on disk", true)
+	}
+}
+
+// resolve returns the note a wiki-link target refers to, by title or alias.
+func (a *App) resolve(target string) *notes.Note {
+	return a.noteResolver().Resolve(target)
+}
+
+// noteResolver returns the resolver for the loaded notes.
+func (a *App) noteResolver() *notes.Resolver {
+	if a.resolver == nil {
+		a.resolver = notes.NewResolver(a.allNotes)
+	}
+	return a.resolver
+}
+
+// warnAliasConflicts reports the first alias claimed by two notes, once.
+func (a *App) warnAliasConflicts() {
+	if len(a.resolver.Conflicts) == 0 {
+		a.aliasWarning = ""
+		return
+	}
+	msg := aliasConflictText(a.resolver.Conflicts[0])
+	if n := len(a.resolver.Conflicts); n > 1 {
+		msg += fmt.Sprintf(" (+%d more)", n-1)
+	}
+	if msg != a.aliasWarning {
+		a.aliasWarning = msg
+		a.setStatus(msg, true)
+	}
+}
+
+func aliasConflictText(c notes.AliasConflict) string {
+	titles := make([]string, len(c.Notes))
+	for i, n := range c.Notes {
+		titles[i] = n.Title
+	}
+	return fmt.Sprintf("alias %q is claimed by %s", c.Alias, strings.Join(titles, ", "))
+}
+
+// listLen is the number of selectable rows in the list view.
+func (a *App) listLen() int {
+	if a.treeMode {
+		return len(a.rows)
+	}
+	return len(a.filtered)
+}
+
+// selectedRow returns the tree row under the cursor, or nil outside tree mode.
+func (a *App) selectedRow() *listRow {
+	if !a.treeMode || a.cursor >= len(a.rows) {
+		return nil
+	}
+	return &a.rows[a.cursor]
+}
+
+// selectedNote returns the note under the list cursor, or nil when the
+// cursor is on a folder or the list is empty.
+func (a *App) selectedNote() *notes.Note {
+	if a.treeMode {
+		if row := a.selectedRow(); row != nil {
+			return row.note
+		}
+		return nil
+	}
+	if a.cursor < len(a.filtered) {
+		return a.filtered[a.cursor]
+	}
+	return nil
+}
+
+// selectedFolder is the folder new notes go into: the folder under the
+// cursor in tree mode, or the top level otherwise.
+func (a *App) selectedFolder() string {
+	row := a.selectedRow()
+	if row == nil {
+		return ""
+	}
+	if row.note != nil {
+		return row.note.Folder
+	}
+	return row.folder
+}
+
+// refreshRows rebuilds the folder tree from filtered and clamps the cursor.
+func (a *App) refreshRows() {
+	if a.treeMode {
+		a.rows = buildTree(a.filtered, a.collapsed)
+	}
+	if a.cursor >= a.listLen() {
+		a.cursor = max(0, a.listLen()-1)
+	}
+	a.ensureVisible()
+}
+
+// moveCursorTo puts the list cursor on the note with the given ID, if shown.
+func (a *App) moveCursorTo(id string) {
+	if id == "" {
+		return
+	}
+	for i := 0; i < a.listLen(); i++ {
+		var n *notes.Note
+		if a.treeMode {
+			n = a.rows[i].note
+		} else {
+			n = a.filtered[i]
+		}
+		if n != nil && n.ID == id {
+			a.cursor = i
+			a.ensureVisible()
+			return
+		}
+	}
+}
+
+func (a *App) moveCursorToFolder(folder string) {
+	for i, row := range a.rows {
+		if row.note == nil && row.folder == folder {
+			a.cursor = i
+			a.ensureVisible()
+			return
+		}
+	}
+}
+
 func (a *App) ensureVisible() {
 	listH := a.height - 6
 	if listH < 1 {
@@ -1423,10 +3244,35 @@ func wordCount(s string) int {
 	return len(strings.Fields(s))
 }
 
-// preprocessLinks replaces [[target]] with `[[target]]` for glamour rendering.
+// preprocessLinks replaces [[target]] with `[[target]]`, and [[target|label]]
+// with `label`, for glamour rendering.
 var wikiLinkRe = regexp.MustCompile(`\[\[([^\]]+)\]\]`)
 
 func preprocessLinks(body string) string {
-	return wikiLinkRe.ReplaceAllString(body, "`[[$1]]`")
+	return wikiLinkRe.ReplaceAllStringFunc(body, func(m string) string {
+		if l := notes.ParseLink(m[2 : len(m)-2]); l.Label != "" {
+			return "`" + l.Label + "`"
+		}
+		return "`" + m + "`"
+	})
 }
 
+// renderEmbed draws an embedded note or section as a blockquote headed by
+// its source, so it reads as borrowed text. Nested embeds nest quotes.
+func renderEmbed(e notes.Embed, content string) string {
+	if e.Err != "" {
+		return "\n> ⚠ can't embed " + e.Link.String() + ": " + e.Err + "\n"
+	}
+	source := e.Note.Title
+	if e.Link.Heading != "" {
+		source += " › " + e.Link.Heading
+	} else if e.Link.Block != "" {
+		source += " › ^" + e.Link.Block
+	}
+	var b strings.Builder
+	b.WriteString("\n> ↳ **" + source + "**\n>\n")
+	for _, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		b.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,321 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yash-srivastava19/grove/internal/ai"
+	"github.com/yash-srivastava19/grove/internal/config"
+	"github.com/yash-srivastava19/grove/internal/notes"
+)
+
+func TestFollowDanglingLinkCreatesNote(t *testing.T) {
+	dir := t.TempDir()
+	s := notes.NewStore(dir)
+	src, _ := s.CreateIn("ideas", "Source", nil)
+	src.Body = "Next: [[New Idea#Plan]]"
+	_ = s.Save(src)
+
+	a := New(&config.Config{NotesDir: dir}, s, nil)
+	ns, _ := s.LoadAll()
+	a.applyNotes(ns)
+	a.openNote(src)
+	a.openLinksPanel()
+
+	a.updateLinks(tea.KeyMsg{Type: tea.KeyEnter})
+	if a.state != stateCreateLink || a.createLink.Target != "New Idea" {
+		t.Fatalf("state %v, link %+v", a.state, a.createLink)
+	}
+	a.updateCreateLink(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")}) // meeting
+	a.updateCreateLink(tea.KeyMsg{Type: tea.KeyEnter})
+
+	n, err := s.Load("ideas/new-idea")
+	if err != nil {
+		t.Fatalf("note not created: %v", err)
+	}
+	if n.Title != "New Idea" {
+		t.Errorf("title: %q", n.Title)
+	}
+	if !strings.HasPrefix(n.Body, "## New Idea") {
+		t.Errorf("expected the meeting template, got %q", n.Body)
+	}
+	for _, p := range notes.Check([]*notes.Note{src, n}) {
+		if p.Kind == notes.BrokenLink {
+			t.Errorf("link still broken: %s", p.Detail)
+		}
+	}
+}
+
+func TestLinkMention(t *testing.T) {
+	dir := t.TempDir()
+	s := notes.NewStore(dir)
+	atlas, _ := s.Create("Project Atlas", nil)
+	log, _ := s.Create("Log", nil)
+	log.Body = "Shipped project atlas v1.\nProject Atlas v2 next."
+	_ = s.Save(log)
+
+	a := New(&config.Config{NotesDir: dir}, s, nil)
+	ns, _ := s.LoadAll()
+	a.applyNotes(ns)
+	a.openNote(atlas)
+	a.openLinksPanel()
+	if len(a.linksMentions) != 2 {
+		t.Fatalf("mentions: %+v", a.linksMentions)
+	}
+
+	// The other mention in the note stays, at its new offset
+	a.linksCursor = len(a.linksOut) + len(a.linksBack)
+	_, cmd := a.updateLinks(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("m")})
+	if cmd == nil || len(a.linksMentions) != 1 || a.linksMentions[0].Line != 2 {
+		t.Fatalf("after linking one mention: %s %+v", a.statusMsg, a.linksMentions)
+	}
+	_, cmd = a.updateLinks(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("m")})
+	if cmd == nil || len(a.linksMentions) != 0 {
+		t.Fatalf("second mention not linked: %s", a.statusMsg)
+	}
+	a.Update(cmd())
+
+	got, _ := s.Load(log.ID)
+	if got.Body != "Shipped [[Project Atlas|project atlas]] v1.\n[[Project Atlas]] v2 next." {
+		t.Errorf("body: %q", got.Body)
+	}
+	if len(a.linksBack) != 1 || a.linksBack[0].ID != log.ID {
+		t.Errorf("backlinks after reload: %v", a.linksBack)
+	}
+}
+
+// fakeStreamer streams its chunks, then blocks until cancelled if hang is set.
+type fakeStreamer struct {
+	chunks []string
+	hang   bool
+}
+
+func (f *fakeStreamer) Name() string  { return "Fake" }
+func (f *fakeStreamer) Model() string { return "fake-1" }
+func (f *fakeStreamer) Ready() error  { return nil }
+func (f *fakeStreamer) Generate(ctx context.Context, req ai.Request) (string, error) {
+	return strings.Join(f.chunks, ""), nil
+}
+func (f *fakeStreamer) Stream(ctx context.Context, req ai.Request, chunk func(string)) error {
+	for _, c := range f.chunks {
+		chunk(c)
+	}
+	if f.hang {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+func TestAIPanelStreams(t *testing.T) {
+	dir := t.TempDir()
+	s := notes.NewStore(dir)
+	n, _ := s.Create("Note", nil)
+	fake := &fakeStreamer{chunks: []string{"part one, ", "part two"}}
+	a := New(&config.Config{NotesDir: dir}, s, ai.NewClient(fake))
+	a.current = n
+	a.state = stateAIPanel
+	a.aiInput.SetValue("summarise")
+
+	_, cmd := a.updateAIPanel(tea.KeyMsg{Type: tea.KeyEnter})
+	var chunks int
+	for cmd != nil {
+		msg := cmd()
+		if _, ok := msg.(aiChunkMsg); ok {
+			chunks++
+			if got := a.aiHistory[0].answer; chunks == 2 && got != "part one, " {
+				t.Errorf("partial answer = %q", got)
+			}
+		}
+		_, cmd = a.Update(msg)
+	}
+	if chunks != 2 || a.aiLoading || a.aiHistory[0].answer != "part one, part two" {
+		t.Errorf("chunks %d, loading %v, answer %q", chunks, a.aiLoading, a.aiHistory[0].answer)
+	}
+
+	// Esc stops an answer mid-stream and keeps what arrived
+	fake.hang = true
+	a.aiInput.SetValue("again")
+	_, cmd = a.updateAIPanel(tea.KeyMsg{Type: tea.KeyEnter})
+	for range fake.chunks {
+		_, cmd = a.Update(cmd())
+	}
+	a.updateAIPanel(tea.KeyMsg{Type: tea.KeyEsc})
+	if a.aiLoading || a.state != stateAIPanel {
+		t.Fatalf("loading %v, state %v after Esc", a.aiLoading, a.state)
+	}
+	last := a.aiHistory[1]
+	if !last.cancelled || last.answer != "part one, part two" {
+		t.Errorf("cancelled entry = %+v", last)
+	}
+	a.Update(aiResponseMsg{stream: a.aiStream, err: context.Canceled})
+	if a.aiError != "" {
+		t.Errorf("late result after cancel shown: %q", a.aiError)
+	}
+}
+
+func TestHistoryMessages(t *testing.T) {
+	got := historyMessages([]aiEntry{
+		{question: "what is atlas?", answer: "A project."},
+		{question: "stopped", answer: "half", cancelled: true},
+		{question: "pending"},
+	})
+	want := []ai.Message{
+		{Role: ai.RoleUser, Content: "what is atlas?"},
+		{Role: ai.RoleAssistant, Content: "A project."},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("historyMessages = %+v, want %+v", got, want)
+	}
+}
+
+func TestSaveAnswer(t *testing.T) {
+	dir := t.TempDir()
+	s := notes.NewStore(dir)
+	n, _ := s.CreateIn("projects", "Atlas", nil)
+	n.Body = "intro\n"
+	s.Save(n)
+	a := New(&config.Config{NotesDir: dir}, s, ai.NewClient(&fakeStreamer{}))
+	a.openNote(n)
+	a.state = stateAIPanel
+	a.aiHistory = []aiEntry{{question: "who owns it?", answer: "## Owner\n\nSam [1]", sources: []ai.Citation{{N: 1, ID: "team", Title: "Team", Section: "Owners"}}}}
+
+	for _, key := range []string{"ctrl+s", "a", "ctrl+s", "n", "ctrl+s", "t"} {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		if key == "ctrl+s" {
+			msg = tea.KeyMsg{Type: tea.KeyCtrlS}
+		}
+		a.Update(msg)
+		if a.statusIsError {
+			t.Fatalf("%s: %s", key, a.statusMsg)
+		}
+	}
+
+	got, _ := s.Load(n.ID)
+	if !strings.HasSuffix(got.Body, "## AI\n\n### who owns it?\n\n#### Owner\n\nSam [1]\n\nSources:\n- [1] [[Team#Owners]]\n") {
+		t.Errorf("appended body:\n%s", got.Body)
+	}
+	if a.current.Body != got.Body {
+		t.Error("viewer not showing the saved answer")
+	}
+	for _, id := range []string{n.ID, "projects/who-owns-it", "daily-" + time.Now().Format("2006-01-02")} {
+		saved, err := s.Load(id)
+		if err != nil {
+			t.Fatalf("load %s: %v", id, err)
+		}
+		if !strings.Contains(saved.Raw, "ai:\n  - model: fake-1\n    question: who owns it?\n    at: ") {
+			t.Errorf("%s: no provenance in\n%s", id, saved.Raw)
+		}
+	}
+	created, _ := s.Load("projects/who-owns-it")
+	if !strings.Contains(created.Body, "Asked about [[Atlas]].") {
+		t.Errorf("new note doesn't link back:\n%s", created.Body)
+	}
+}
+
+func TestAILogReloads(t *testing.T) {
+	dir := t.TempDir()
+	s := notes.NewStore(dir)
+	n, _ := s.Create("Note", nil)
+	cfg := &config.Config{NotesDir: dir, DataDir: t.TempDir()}
+	fake := &fakeStreamer{chunks: []string{"an answer"}}
+	a := New(cfg, s, ai.NewClient(fake))
+	a.openNote(n)
+	a.state = stateAIPanel
+	a.aiInput.SetValue("what is this?")
+	_, cmd := a.updateAIPanel(tea.KeyMsg{Type: tea.KeyEnter})
+	for cmd != nil {
+		_, cmd = a.Update(cmd())
+	}
+
+	// A new session shows the answer when the note is opened again
+	b := New(cfg, s, ai.NewClient(fake))
+	if len(b.vaultAIHistory) != 0 {
+		t.Errorf("note answer in vault history: %+v", b.vaultAIHistory)
+	}
+	b.openNote(n)
+	if len(b.aiHistory) != 1 || b.aiHistory[0].answer != "an answer" || b.aiHistory[0].asked.IsZero() {
+		t.Errorf("reloaded history = %+v", b.aiHistory)
+	}
+}
+
+func TestTagReview(t *testing.T) {
+	dir := t.TempDir()
+	s := notes.NewStore(dir)
+	s.Create("Tagged", []string{"work"})
+	one, _ := s.Create("One", nil)
+	two, _ := s.Create("Two", nil)
+	a := New(&config.Config{NotesDir: dir}, s, ai.NewClient(&fakeStreamer{chunks: []string{"new-idea\nWork\n"}}))
+	a.Update(a.cmdLoadNotes()())
+
+	_, cmd := a.updateList(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("T")})
+	for cmd != nil {
+		_, cmd = a.Update(cmd())
+	}
+	if a.state != stateTags || len(a.tagReviews) != 2 {
+		t.Fatalf("state %v with %d reviews", a.state, len(a.tagReviews))
+	}
+	for _, r := range a.tagReviews {
+		if len(r.suggestions) != 2 || r.suggestions[0].Tag != "work" || slices.Contains(r.chosen, true) {
+			t.Fatalf("review %s: %+v %v", r.note.ID, r.suggestions, r.chosen)
+		}
+	}
+
+	a.width, a.height = 80, 24
+	if view := a.View(); !strings.Contains(view, "[ ] work") || !strings.Contains(view, "[ ] new-idea") {
+		t.Errorf("review screen:\n%s", view)
+	}
+
+	// Leave the first note alone, tick both tags on the second
+	for _, key := range []string{"j", "j", " ", "j", " "} {
+		a.updateTags(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	}
+	a.updateTags(tea.KeyMsg{Type: tea.KeyEnter})
+	if a.state != stateList {
+		t.Errorf("state after Enter = %v", a.state)
+	}
+	got1, _ := s.Load(one.ID)
+	got2, _ := s.Load(two.ID)
+	if len(got1.Tags) != 0 || strings.Join(got2.Tags, ",") != "work,new-idea" {
+		t.Errorf("tags: one %v, two %v", got1.Tags, got2.Tags)
+	}
+}
+
+func TestTagReview_fromViewer(t *testing.T) {
+	dir := t.TempDir()
+	s := notes.NewStore(dir)
+	for i := 0; i < maxTagBatch+2; i++ {
+		s.Create(fmt.Sprintf("Note %d", i), nil)
+	}
+	a := New(&config.Config{NotesDir: dir}, s, ai.NewClient(&fakeStreamer{chunks: []string{"work\n"}}))
+	a.Update(a.cmdLoadNotes()())
+
+	a.width, a.height = 80, 24
+	a.updateList(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("T")})
+	if view := a.View(); len(a.tagReviews) != maxTagBatch || !strings.Contains(view, "2 more") {
+		t.Errorf("%d reviews, screen:\n%s", len(a.tagReviews), view)
+	}
+	a.stopTags()
+
+	// Tagging the open note updates the viewer, so saving it again is no conflict
+	n, _ := s.Load("note-0")
+	a.openNote(n)
+	_, cmd := a.updateViewer(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("T")})
+	for cmd != nil {
+		_, cmd = a.Update(cmd())
+	}
+	a.updateTags(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(" ")})
+	a.updateTags(tea.KeyMsg{Type: tea.KeyEnter})
+	if !notes.HasTag(a.current, "work") {
+		t.Fatalf("viewer note not refreshed: %v", a.current.Tags)
+	}
+	if err := s.Save(a.current); err != nil {
+		t.Errorf("saving the open note: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/yash-srivastava19/grove/internal/notes"
+)
+
+// graphRow is one line of the neighborhood view: a note, drawn as a branch
+// under the note it was reached from.
+type graphRow struct {
+	note   *notes.Note
+	depth  int    // hops from the center
+	prefix string // tree branches drawn before the arrow
+	arrow  string // "→" parent links to note, "←" note links to parent, "↔" both
+}
+
+// buildNeighborhood lays out the notes within hops links of center as a
+// tree, each note under the first note one hop nearer that links with it.
+// Siblings are sorted by title.
+func buildNeighborhood(g *notes.Graph, center *notes.Note, hops int) []graphRow {
+	dist := g.Neighborhood(center, hops)
+	children := map[*notes.Note][]*notes.Note{}
+	placed := map[*notes.Note]bool{center: true}
+	level := []*notes.Note{center}
+	for d := 1; len(level) > 0; d++ {
+		var next []*notes.Note
+		for _, n := range level {
+			for _, m := range g.Neighbors(n) {
+				if dd, ok := dist[m]; ok && dd == d && !placed[m] {
+					placed[m] = true
+					children[n] = append(children[n], m)
+					next = append(next, m)
+				}
+			}
+			sort.SliceStable(children[n], func(i, j int) bool {
+				return strings.ToLower(children[n][i].Title) < strings.ToLower(children[n][j].Title)
+			})
+		}
+		level = next
+	}
+
+	rows := []graphRow{{note: center}}
+	var walk func(parent *notes.Note, depth int, indent string)
+	walk = func(parent *notes.Note, depth int, indent string) {
+		kids := children[parent]
+		for i, n := range kids {
+			branch, next := "├─", "│  "
+			if i == len(kids)-1 {
+				branch, next = "└─", "   "
+			}
+			rows = append(rows, graphRow{
+				note:   n,
+				depth:  depth,
+				prefix: indent + branch,
+				arrow:  linkArrow(g, parent, n),
+			})
+			walk(n, depth+1, indent+next)
+		}
+	}
+	walk(center, 1, "")
+	return rows
+}
+
+func linkArrow(g *notes.Graph, from, to *notes.Note) string {
+	switch fwd, back := g.Links(from, to), g.Links(to, from); {
+	case fwd && back:
+		return "↔"
+	case back:
+		return "←"
+	}
+	return "→"
+}
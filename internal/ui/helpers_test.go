@@ -1,8 +1,13 @@
 package ui
 
 import (
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/yash-srivastava19/grove/internal/notes"
 )
 
 func TestTruncate(t *testing.T) {
@@ -46,3 +51,135 @@ func TestHumanTime(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildTree(t *testing.T) {
+	ns := []*notes.Note{
+		{ID: "top", Title: "Top"},
+		{ID: "projects/atlas/kickoff", Title: "Kickoff", Folder: "projects/atlas"},
+		{ID: "projects/plan", Title: "Plan", Folder: "projects"},
+		{ID: "archive/old", Title: "Old", Folder: "archive"},
+	}
+
+	rows := buildTree(ns, map[string]bool{})
+	var got []string
+	for _, r := range rows {
+		if r.note != nil {
+			got = append(got, r.note.Title)
+		} else {
+			got = append(got, r.folder+"/")
+		}
+	}
+	want := []string{"archive/", "Old", "projects/", "projects/atlas/", "Kickoff", "Plan", "Top"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("buildTree order:\n got %v\nwant %v", got, want)
+	}
+	if rows[2].count != 2 {
+		t.Errorf("projects/ count: got %d, want 2", rows[2].count)
+	}
+	if rows[4].depth != 2 {
+		t.Errorf("Kickoff depth: got %d, want 2", rows[4].depth)
+	}
+
+	collapsed := buildTree(ns, map[string]bool{"projects": true})
+	if len(collapsed) != 4 {
+		t.Errorf("collapsed projects: got %d rows, want 4", len(collapsed))
+	}
+}
+
+func TestPreprocessLinks(t *testing.T) {
+	got := preprocessLinks("see [[Roadmap]], [[Roadmap#Q3|the plan]] and [[#Setup]]")
+	want := "see `[[Roadmap]]`, `the plan` and `[[#Setup]]`"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestAnchorLine(t *testing.T) {
+	body := "# Overview\n\nIntro.\n\n## Q3 goals\n\nShip it. ^ship\n\n## Later\n\nSome day.\n"
+	r, err := glamour.NewTermRenderer(glamour.WithStandardStyle("dark"), glamour.WithWordWrap(60))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := r.Render(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(out, "\n")
+
+	tests := []struct {
+		link notes.Link
+		want string // text on the line found
+	}{
+		{notes.Link{Heading: "Overview"}, "Overview"},
+		{notes.Link{Heading: "q3 goals"}, "Q3 goals"},
+		{notes.Link{Block: "ship"}, "Ship it."},
+		{notes.Link{Heading: "Missing"}, ""},
+	}
+	for _, tt := range tests {
+		i := anchorLine(lines, tt.link)
+		if tt.want == "" {
+			if i != -1 {
+				t.Errorf("%+v: expected no match, got line %d", tt.link, i)
+			}
+			continue
+		}
+		if i == -1 || !strings.Contains(ansi.Strip(lines[i]), tt.want) {
+			t.Errorf("%+v: got line %d, want one containing %q", tt.link, i, tt.want)
+		}
+	}
+}
+
+func TestBuildNeighborhood(t *testing.T) {
+	hub := &notes.Note{ID: "hub", Title: "Hub", Body: "[[Beta]] [[alpha]]"}
+	alpha := &notes.Note{ID: "alpha", Title: "Alpha", Body: "[[Hub]] [[Deep]]"}
+	beta := &notes.Note{ID: "beta", Title: "Beta"}
+	deep := &notes.Note{ID: "deep", Title: "Deep"}
+	inbound := &notes.Note{ID: "in", Title: "Inbound", Body: "[[Beta]]"}
+	g := notes.BuildGraph([]*notes.Note{hub, alpha, beta, deep, inbound})
+
+	var got []string
+	for _, r := range buildNeighborhood(g, hub, 2) {
+		got = append(got, r.prefix+r.arrow+r.note.Title)
+	}
+	want := []string{
+		"Hub",
+		"├─↔Alpha",
+		"│  └─→Deep",
+		"└─→Beta",
+		"   └─←Inbound",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("buildNeighborhood:\n got %q\nwant %q", got, want)
+	}
+
+	if rows := buildNeighborhood(g, hub, 1); len(rows) != 3 {
+		t.Errorf("1 hop: %d rows, want 3", len(rows))
+	}
+}
+
+func TestRenderEmbed(t *testing.T) {
+	src := &notes.Note{ID: "guide", Title: "Guide"}
+	got := renderEmbed(notes.Embed{Link: notes.Link{Target: "Guide", Heading: "Setup"}, Note: src}, "## Setup\n\nstep one\n")
+	want := "\n> ↳ **Guide › Setup**\n>\n> ## Setup\n>\n> step one\n"
+	if got != want {
+		t.Errorf("renderEmbed = %q, want %q", got, want)
+	}
+
+	missing := renderEmbed(notes.Embed{Link: notes.Link{Target: "Gone"}, Err: "no note named Gone"}, "")
+	if missing != "\n> ⚠ can't embed Gone: no note named Gone\n" {
+		t.Errorf("missing embed = %q", missing)
+	}
+}
+
+func TestAnswerTitle(t *testing.T) {
+	tests := []struct{ question, want string }{
+		{"what is  atlas?", "what is atlas"},
+		{"how did the hosting decision for project atlas change between the first and second reviews?",
+			"how did the hosting decision for project atlas change…"},
+	}
+	for _, tt := range tests {
+		if got := answerTitle(tt.question); got != tt.want {
+			t.Errorf("answerTitle(%q) = %q, want %q", tt.question, got, tt.want)
+		}
+	}
+}
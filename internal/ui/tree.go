@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/yash-srivastava19/grove/internal/notes"
+)
+
+// listRow is one line of the folder tree: either a folder header or a note.
+type listRow struct {
+	folder string      // full folder path for folder rows
+	name   string      // last path element for folder rows
+	depth  int         // indentation level
+	count  int         // notes under this folder, recursively
+	note   *notes.Note // nil for folder rows
+}
+
+// buildTree lays out ns as a folder tree. Folders come first at each level,
+// sorted by name; notes keep the order they have in ns. Children of folders
+// in collapsed are omitted.
+func buildTree(ns []*notes.Note, collapsed map[string]bool) []listRow {
+	children := map[string][]string{} // folder -> direct subfolders
+	byFolder := map[string][]*notes.Note{}
+	counts := map[string]int{}
+	known := map[string]bool{"": true}
+
+	for _, n := range ns {
+		byFolder[n.Folder] = append(byFolder[n.Folder], n)
+		for f := n.Folder; f != ""; f = notes.ParentFolder(f) {
+			counts[f]++
+			if !known[f] {
+				known[f] = true
+				p := notes.ParentFolder(f)
+				children[p] = append(children[p], f)
+			}
+		}
+	}
+	for _, c := range children {
+		sort.Strings(c)
+	}
+
+	var rows []listRow
+	var walk func(folder string, depth int)
+	walk = func(folder string, depth int) {
+		for _, sub := range children[folder] {
+			rows = append(rows, listRow{
+				folder: sub,
+				name:   sub[strings.LastIndex(sub, "/")+1:],
+				depth:  depth,
+				count:  counts[sub],
+			})
+			if !collapsed[sub] {
+				walk(sub, depth+1)
+			}
+		}
+		for _, n := range byFolder[folder] {
+			rows = append(rows, listRow{depth: depth, note: n})
+		}
+	}
+	walk("", 0)
+	return rows
+}
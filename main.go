@@ -1,17 +1,24 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/yash-srivastava19/grove/internal/ai"
 	"github.com/yash-srivastava19/grove/internal/config"
 	"github.com/yash-srivastava19/grove/internal/notes"
+	"github.com/yash-srivastava19/grove/internal/search"
 	"github.com/yash-srivastava19/grove/internal/templates"
 	"github.com/yash-srivastava19/grove/internal/ui"
 )
@@ -22,21 +29,53 @@ const usage = `grove — your knowledge garden in the terminal
 
 Usage:
   grove                              open TUI
-  grove new [--template T] <title>   create note, open in $EDITOR
+  grove new [--template T] [--folder F] <title>
+                                     create note, open in $EDITOR
   grove today                        open today's daily note in $EDITOR
   grove add <text>                   append quick thought to today's note
-  grove search <query>               search notes (non-interactive)
+  grove search <query>               ranked search; see "Search syntax" below
+  grove search --save NAME <query>   save a search (and run it)
+  grove search @NAME                 run a saved search; --saved lists them
   grove list                         list all notes
-  grove ask <question>               ask AI about your entire vault
+  grove rename [--dry-run] <id> <new title>
+                                     retitle a note, move its file, fix links
+  grove doctor [--fix]               report broken links, orphans and other
+                                     problems; --fix creates stubs for
+                                     missing link targets
+  grove graph [--format dot|json|mermaid]
+                                     export the link graph (default dot)
+  grove graph --path <from> <to>     shortest chain of links between notes
+  grove export [--flatten] <id>      print a note's markdown; --flatten
+                                     replaces ![[embeds]] with their text
+  grove ask [--continue] <question>  ask AI about your entire vault;
+                                     --continue follows up on the last ask
+  grove ask --history [note]         earlier questions about the vault, or
+                                     about a note (the AI panel's)
+  grove review --week|--month [date] [--force]
+                                     AI digest of the period's daily and
+                                     updated notes, written to a
+                                     weekly-YYYY-Www / monthly-YYYY-MM note
+  grove tag suggest <id>|--untagged  AI tag suggestions from the vault's
+                                     tags, for a note or every untagged one
   grove stats                        show vault statistics
   grove version
 
 Templates: default, meeting, brainstorm, research
 
+Search syntax:
+  word "exact phrase" pref*          text, best matches first
+  tag:work -tag:archive              tag filters (- or NOT negates)
+  title:"kickoff"  folder:projects   title / folder filters
+  links:Roadmap  has:todo|link|tag   link and content filters
+  updated:>2026-09-01  created:<=7d  dates: YYYY-MM-DD, Nd, Nw, today
+  a OR b   (grouping)                boolean operators
+
 TUI keys:
   j/k  navigate    Enter open    n new    N new with template    t today
   /    search      d delete      e edit   A ask AI               @ vault AI
-  L    links       ?    help     q quit
+  L    links       f    folders  R    rename   D    doctor
+  M    link map (in viewer)       T    AI tag suggestions
+  ?    help        q    quit
 `
 
 func main() {
@@ -46,6 +85,11 @@ func main() {
 	}
 
 	store := notes.NewStore(cfg.NotesDir)
+	store.EnableIndex(cfg.CacheDir)
+	aiLog := ai.OpenLog(cfg.AILogFile())
+	store.OnRename(func(oldID, newID string) error {
+		return aiLog.Rename(oldID, newID)
+	})
 
 	// First run: create welcome note if vault is empty
 	ensureWelcome(store)
@@ -65,29 +109,37 @@ func main() {
 		fmt.Print(usage)
 
 	case "new", "n":
-		// Parse optional --template flag
+		// Parse optional --template and --folder flags
 		tmplName := "default"
-		rest := args[1:]
-		for i := 0; i < len(rest); i++ {
-			if rest[i] == "--template" || rest[i] == "-t" {
-				if i+1 >= len(rest) {
+		folder := ""
+		var rest []string
+		for i := 1; i < len(args); i++ {
+			switch {
+			case args[i] == "--template" || args[i] == "-t":
+				if i+1 >= len(args) {
 					die("--template requires a name (default, meeting, brainstorm, research)")
 				}
-				tmplName = rest[i+1]
-				rest = append(rest[:i], rest[i+2:]...)
-				break
-			}
-			if strings.HasPrefix(rest[i], "--template=") {
-				tmplName = strings.TrimPrefix(rest[i], "--template=")
-				rest = append(rest[:i], rest[i+1:]...)
-				break
+				tmplName = args[i+1]
+				i++
+			case strings.HasPrefix(args[i], "--template="):
+				tmplName = strings.TrimPrefix(args[i], "--template=")
+			case args[i] == "--folder" || args[i] == "-f":
+				if i+1 >= len(args) {
+					die("--folder requires a path, e.g. projects/atlas")
+				}
+				folder = args[i+1]
+				i++
+			case strings.HasPrefix(args[i], "--folder="):
+				folder = strings.TrimPrefix(args[i], "--folder=")
+			default:
+				rest = append(rest, args[i])
 			}
 		}
 		title := strings.Join(rest, " ")
 		if title == "" {
-			die("usage: grove new [--template T] <title>")
+			die("usage: grove new [--template T] [--folder F] <title>")
 		}
-		note, err := store.Create(title, nil)
+		note, err := store.CreateIn(folder, title, nil)
 		if err != nil {
 			die("create: %v", err)
 		}
@@ -121,8 +173,13 @@ func main() {
 		if err != nil {
 			die("append: %v", err)
 		}
-		_, _ = fmt.Fprintln(f, line)
-		f.Close()
+		if _, err := fmt.Fprintln(f, line); err != nil {
+			f.Close()
+			die("append: %v", err)
+		}
+		if err := f.Close(); err != nil {
+			die("append: %v", err)
+		}
 		fmt.Printf("added to %s\n", note.ID)
 
 	case "list", "ls":
@@ -133,53 +190,390 @@ func main() {
 		for _, n := range all {
 			fmt.Printf("%-40s  %s\n", n.ID, n.Title)
 		}
+		warnAliasConflicts(all)
 
 	case "search", "s":
-		query := strings.Join(args[1:], " ")
+		rest := args[1:]
+		saveAs := ""
+		if len(rest) > 0 && rest[0] == "--saved" {
+			for _, s := range cfg.SavedSearches {
+				fmt.Printf("@%-20s  %s\n", s.Name, s.Query)
+			}
+			return
+		}
+		if len(rest) > 0 && rest[0] == "--save" {
+			if len(rest) < 2 {
+				die("usage: grove search --save NAME <query>")
+			}
+			saveAs = strings.TrimPrefix(rest[1], "@")
+			rest = rest[2:]
+		} else if len(rest) > 0 && strings.HasPrefix(rest[0], "--save=") {
+			saveAs = strings.TrimPrefix(strings.TrimPrefix(rest[0], "--save="), "@")
+			rest = rest[1:]
+		}
+		query := strings.Join(rest, " ")
 		if query == "" {
-			die("usage: grove search <query>")
+			die("usage: grove search [--save NAME] <query|@NAME>")
+		}
+		expanded, err := search.ExpandSaved(query, cfg.SavedSearch)
+		if err != nil {
+			die("search: %v", err)
+		}
+		if _, err := search.Parse(expanded); err != nil {
+			die("search: %v", err)
+		}
+		if saveAs != "" {
+			if err := cfg.SaveSearch(saveAs, expanded); err != nil {
+				die("save search: %v", err)
+			}
+			fmt.Fprintf(os.Stderr, "saved as @%s\n", saveAs)
 		}
 		all, err := store.LoadAll()
 		if err != nil {
 			die("search: %v", err)
 		}
-		found := 0
-		ql := strings.ToLower(query)
-		for _, n := range all {
-			haystack := strings.ToLower(n.Title + " " + strings.Join(n.Tags, " ") + " " + n.Body)
-			if strings.Contains(haystack, ql) {
-				fmt.Printf("%-40s  %s\n", n.ID, n.Title)
-				found++
+		results, err := search.Build(all).Search(expanded, 0)
+		if err != nil {
+			die("search: %v", err)
+		}
+		for _, r := range results {
+			fmt.Printf("%-40s  %s\n", r.Note.ID, r.Note.Title)
+			for _, sn := range r.Snippets {
+				fmt.Printf("  %5d  %s\n", sn.Line, sn.Text)
 			}
 		}
-		if found == 0 {
+		if len(results) == 0 {
 			fmt.Fprintf(os.Stderr, "no notes match %q\n", query)
 			os.Exit(1)
 		}
 
+	case "rename", "mv":
+		dryRun := false
+		var rest []string
+		for _, a := range args[1:] {
+			if a == "--dry-run" || a == "-n" {
+				dryRun = true
+			} else {
+				rest = append(rest, a)
+			}
+		}
+		if len(rest) < 2 {
+			die("usage: grove rename [--dry-run] <id> <new title>")
+		}
+		all, err := store.LoadAll()
+		if err != nil {
+			die("rename: %v", err)
+		}
+		id := findNoteID(all, rest[0])
+		if id == "" {
+			die("rename: no note %q (see grove list)", rest[0])
+		}
+		newTitle := strings.Join(rest[1:], " ")
+		var plan *notes.RenamePlan
+		if dryRun {
+			plan, err = store.PlanRename(id, newTitle)
+		} else {
+			plan, err = store.Rename(id, newTitle)
+		}
+		if err != nil {
+			die("rename: %v", err)
+		}
+		printRenamePlan(plan, dryRun)
+
+	case "doctor":
+		fix := len(args) > 1 && args[1] == "--fix"
+		all, err := store.LoadAll()
+		if err != nil {
+			die("doctor: %v", err)
+		}
+		problems := notes.Check(all)
+		if len(problems) == 0 {
+			fmt.Printf("no problems in %d notes\n", len(all))
+			return
+		}
+		printProblems(problems)
+		if !fix {
+			if len(notes.Dangling(problems)) > 0 {
+				fmt.Println("\nrun grove doctor --fix to create stub notes for missing link targets")
+			}
+			os.Exit(1)
+		}
+		fmt.Println()
+		for _, d := range notes.Dangling(problems) {
+			n, err := store.CreateStub(d.Title, d.From)
+			if err != nil {
+				die("stub %q: %v", d.Title, err)
+			}
+			fmt.Printf("created %s\n", n.ID)
+		}
+
+	case "graph":
+		format := "dot"
+		var path []string
+		for i := 1; i < len(args); i++ {
+			switch {
+			case args[i] == "--format" && i+1 < len(args):
+				i++
+				format = args[i]
+			case args[i] == "--path" && i+2 < len(args):
+				path = args[i+1 : i+3]
+				i += 2
+			default:
+				die("usage: grove graph [--format dot|json|mermaid] [--path <from> <to>]")
+			}
+		}
+		all, err := store.LoadAll()
+		if err != nil {
+			die("graph: %v", err)
+		}
+		g := notes.BuildGraph(all)
+		if path != nil {
+			printPath(g, all, path[0], path[1])
+			return
+		}
+		switch format {
+		case "dot":
+			err = g.WriteDOT(os.Stdout)
+		case "json":
+			err = g.WriteJSON(os.Stdout)
+		case "mermaid":
+			err = g.WriteMermaid(os.Stdout)
+		default:
+			die("graph: unknown format %q (want dot, json or mermaid)", format)
+		}
+		if err != nil {
+			die("graph: %v", err)
+		}
+
+	case "export":
+		flatten := false
+		var rest []string
+		for _, a := range args[1:] {
+			if a == "--flatten" {
+				flatten = true
+			} else {
+				rest = append(rest, a)
+			}
+		}
+		if len(rest) != 1 {
+			die("usage: grove export [--flatten] <id>")
+		}
+		all, err := store.LoadAll()
+		if err != nil {
+			die("export: %v", err)
+		}
+		id := findNoteID(all, rest[0])
+		if id == "" {
+			die("export: no note %q (see grove list)", rest[0])
+		}
+		n, err := store.Load(id)
+		if err != nil {
+			die("export: %v", err)
+		}
+		out := n.Raw
+		if flatten {
+			// Keep the frontmatter as written; only the body changes
+			front, ok := strings.CutSuffix(n.Raw, n.Body)
+			if !ok {
+				front = notes.BuildFrontmatter(n)
+			}
+			out = front + notes.Flatten(n, notes.NewResolver(all))
+		}
+		fmt.Print(out)
+
 	case "ask":
-		question := strings.Join(args[1:], " ")
+		cont, history := false, false
+		var rest []string
+		for _, a := range args[1:] {
+			switch a {
+			case "--continue", "-c":
+				cont = true
+			case "--history":
+				history = true
+			default:
+				rest = append(rest, a)
+			}
+		}
+		if history {
+			printAILog(store, aiLog, strings.Join(rest, " "))
+			return
+		}
+		question := strings.Join(rest, " ")
 		if question == "" {
-			die("usage: grove ask <question>")
+			die("usage: grove ask [--continue] <question>")
 		}
-		if cfg.GeminiKey == "" {
-			fmt.Fprintln(os.Stderr, "grove: no Gemini API key configured (check ~/.config/pairy/config.json or set GEMINI_API_KEY)")
-			os.Exit(1)
+		aiClient := newAIClient(cfg)
+		if err := aiClient.Ready(); err != nil {
+			die("%v", err)
 		}
 		all, err := store.LoadAll()
 		if err != nil {
 			die("load notes: %v", err)
 		}
-		aiClient := ai.NewClient(cfg.GeminiKey, cfg.GeminiModel)
-		ctx := make([]ai.NoteContext, len(all))
-		for i, n := range all {
-			ctx[i] = ai.NoteContext{Title: n.Title, Tags: n.Tags, Body: n.Body}
+
+		// Every ask starts a session; --continue carries the last one on
+		session := &ai.Session{}
+		sessionFile := cfg.SessionFile()
+		if cont {
+			if sessionFile == "" {
+				die("ask: --continue needs a data dir to keep the conversation in")
+			}
+			if session, err = ai.LoadSession(sessionFile); err != nil {
+				die("ask: session: %v", err)
+			}
+		}
+		sources := vaultSources(all, ai.FollowUp(session.Messages, question))
+		// Ctrl-C stops the answer but still ends the line cleanly
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		answer, err := aiClient.AskVault(ctx, sources, session.Messages, question, func(chunk string) {
+			fmt.Print(chunk)
+		})
+		fmt.Println()
+		if ctx.Err() != nil {
+			os.Exit(130)
+		}
+		if err != nil {
+			die("AI error: %v", err)
+		}
+		if cites := ai.Citations(answer, sources); len(cites) > 0 {
+			fmt.Println("\nSources:")
+			for _, c := range cites {
+				fmt.Println("  " + c.String())
+			}
+		}
+		session.Add(question, answer)
+		if sessionFile != "" {
+			if err := session.Save(sessionFile); err != nil {
+				fmt.Fprintf(os.Stderr, "grove: ask: saving session: %v\n", err)
+			}
+		}
+		err = aiLog.Append(ai.LogEntry{
+			Time:     time.Now(),
+			Model:    aiClient.Model(),
+			Question: question,
+			Answer:   answer,
+			Sources:  ai.Citations(answer, sources),
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "grove: ask: saving to AI log: %v\n", err)
+		}
+
+	case "review":
+		kind, date, force := "", "", false
+		for _, a := range args[1:] {
+			switch {
+			case a == "--week":
+				kind = "week"
+			case a == "--month":
+				kind = "month"
+			case a == "--force":
+				force = true
+			case date == "" && !strings.HasPrefix(a, "-"):
+				date = a
+			default:
+				kind = ""
+			}
+		}
+		if kind == "" {
+			die("usage: grove review --week|--month [date] [--force]")
+		}
+		period, err := notes.ParsePeriod(kind, date, time.Now())
+		if err != nil {
+			die("review: %v", err)
+		}
+		aiClient := newAIClient(cfg)
+		if err := aiClient.Ready(); err != nil {
+			die("%v", err)
+		}
+		all, err := store.LoadAll()
+		if err != nil {
+			die("review: %v", err)
+		}
+		for _, n := range all {
+			if n.ID == period.ID && strings.TrimSpace(n.Body) != "" && !force {
+				die("review: %s already exists; --force rewrites it", period.ID)
+			}
+		}
+		sources := period.Notes(all)
+		if len(sources) == 0 {
+			fmt.Fprintf(os.Stderr, "no notes from %s\n", period.Name)
+			os.Exit(1)
+		}
+		var contexts []ai.NoteContext
+		for _, n := range sources {
+			contexts = append(contexts, ai.NoteContext{ID: n.ID, Title: n.Title, Tags: n.Tags, Body: n.Body})
+		}
+		fmt.Fprintf(os.Stderr, "reviewing %d notes from %s\n\n", len(sources), period.Name)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		digest, err := aiClient.Digest(ctx, period.Name, contexts, func(chunk string) {
+			fmt.Print(chunk)
+		})
+		fmt.Println()
+		if ctx.Err() != nil {
+			os.Exit(130)
 		}
-		answer, err := aiClient.AskVault(ctx, question)
 		if err != nil {
 			die("AI error: %v", err)
 		}
-		fmt.Println(answer)
+
+		n, err := store.LoadOrCreate(period.ID, period.Title, []string{"review"})
+		if err != nil {
+			die("review: %v", err)
+		}
+		var body strings.Builder
+		body.WriteString(strings.TrimSpace(digest) + "\n\n## Sources\n\n")
+		for _, src := range sources {
+			body.WriteString("- [[" + src.Title + "]]\n")
+		}
+		n.Body = body.String()
+		notes.RecordAI(n, aiClient.Model(), "review of "+period.Name, time.Now())
+		if err := store.Save(n); err != nil {
+			die("review: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "\nwrote %s\n", n.ID)
+
+	case "tag":
+		if len(args) != 3 || args[1] != "suggest" {
+			die("usage: grove tag suggest <id>|--untagged")
+		}
+		aiClient := newAIClient(cfg)
+		if err := aiClient.Ready(); err != nil {
+			die("%v", err)
+		}
+		all, err := store.LoadAll()
+		if err != nil {
+			die("tag: %v", err)
+		}
+		todo := notes.Untagged(all)
+		if args[2] != "--untagged" {
+			id := findNoteID(all, args[2])
+			if id == "" {
+				die("tag: no note %q (see grove list)", args[2])
+			}
+			n, err := store.Load(id)
+			if err != nil {
+				die("tag: %v", err)
+			}
+			todo = []*notes.Note{n}
+		} else if len(todo) == 0 {
+			fmt.Println("every note has tags")
+			return
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		in := bufio.NewReader(os.Stdin)
+		vocab := notes.Vocabulary(all)
+		for i, n := range todo {
+			if len(todo) > 1 {
+				fmt.Printf("\n(%d/%d) %s  %s\n", i+1, len(todo), n.ID, n.Title)
+			}
+			if !suggestTags(ctx, aiClient, store, n, vocab, in) {
+				break
+			}
+		}
 
 	case "stats":
 		all, err := store.LoadAll()
@@ -195,7 +589,7 @@ func main() {
 		oldest := all[0]
 		newest := all[0]
 		for _, n := range all {
-			totalWords += len(strings.Fields(n.Body))
+			totalWords += n.Words
 			for _, t := range n.Tags {
 				tagCount[t]++
 			}
@@ -237,6 +631,7 @@ func main() {
 			}
 			fmt.Println()
 		}
+		warnAliasConflicts(all)
 
 	default:
 		fmt.Fprintf(os.Stderr, "grove: unknown command %q\n\n", args[0])
@@ -246,14 +641,141 @@ func main() {
 }
 
 func runTUI(cfg *config.Config, store *notes.Store) {
-	aiClient := ai.NewClient(cfg.GeminiKey, cfg.GeminiModel)
-	app := ui.New(cfg, store, aiClient)
+	app := ui.New(cfg, store, newAIClient(cfg))
 	p := tea.NewProgram(app, tea.WithAltScreen(), tea.WithMouseCellMotion())
-	if _, err := p.Run(); err != nil {
+	_, err := p.Run()
+	app.Close()
+	if err != nil {
 		die("%v", err)
 	}
 }
 
+// suggestTags shows the AI's tag suggestions for n and adds the ones the
+// user picks on in. It returns false when there's no point going on to
+// another note: the user hit Ctrl-C or closed the input.
+func suggestTags(ctx context.Context, c *ai.Client, store *notes.Store, n *notes.Note, vocab []notes.TagCount, in *bufio.Reader) bool {
+	names := make([]string, len(vocab))
+	uses := map[string]int{}
+	for i, tc := range vocab {
+		names[i] = tc.Tag
+		uses[tc.Tag] = tc.Notes
+	}
+	suggestions, err := c.SuggestTags(ctx, n.Title, n.Body, n.Tags, names)
+	if ctx.Err() != nil {
+		os.Exit(130)
+	}
+	if err != nil {
+		die("AI error: %v", err)
+	}
+	if len(suggestions) == 0 {
+		fmt.Println("no suggestions")
+		return true
+	}
+	for i, sg := range suggestions {
+		note := "new"
+		if sg.Known {
+			note = fmt.Sprintf("%d notes", uses[sg.Tag])
+			if uses[sg.Tag] == 1 {
+				note = "1 note"
+			}
+		}
+		fmt.Printf("  %d. %-24s  %s\n", i+1, sg.Tag, note)
+	}
+
+	fmt.Fprint(os.Stderr, "add which? (e.g. 1 3, a for all, Enter for none) ")
+	line, err := in.ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Fprintln(os.Stderr)
+		return false
+	}
+	var add []string
+	pick := func(tag string) {
+		if !notes.HasTag(n, tag) && !slices.Contains(add, tag) {
+			add = append(add, tag)
+		}
+	}
+	for _, f := range strings.FieldsFunc(line, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+		if f == "a" || f == "all" {
+			for _, sg := range suggestions {
+				pick(sg.Tag)
+			}
+			continue
+		}
+		k, err := strconv.Atoi(f)
+		if err != nil || k < 1 || k > len(suggestions) {
+			fmt.Fprintf(os.Stderr, "grove: skipping %q: not one of 1-%d\n", f, len(suggestions))
+			continue
+		}
+		pick(suggestions[k-1].Tag)
+	}
+	if len(add) == 0 {
+		return true
+	}
+	n.Tags = append(n.Tags, add...)
+	if err := store.Save(n); err != nil {
+		die("tag: %v", err)
+	}
+	fmt.Printf("tagged %s: %s\n", n.ID, strings.Join(add, ", "))
+	return true
+}
+
+// printAILog prints the answered questions in log about the note ref
+// names, or about the vault if ref is "", oldest first.
+func printAILog(store *notes.Store, log *ai.Log, ref string) {
+	id := ""
+	if ref != "" {
+		all, err := store.LoadAll()
+		if err != nil {
+			die("ask: %v", err)
+		}
+		if id = findNoteID(all, ref); id == "" {
+			die("ask: no note %q (see grove list)", ref)
+		}
+	}
+	entries, err := log.ForNote(id)
+	if err != nil {
+		die("ask: history: %v", err)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(os.Stderr, "no questions asked yet")
+		return
+	}
+	for i, e := range entries {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s  %s\n", e.Time.Local().Format("2006-01-02 15:04"), e.Question)
+		fmt.Println(e.Answer)
+		for _, c := range e.Sources {
+			fmt.Println("  " + c.String())
+		}
+	}
+}
+
+// vaultSources picks the passages of all most relevant to question, to
+// send with it to the AI.
+func vaultSources(all []*notes.Note, question string) []ai.NoteContext {
+	var out []ai.NoteContext
+	for _, p := range search.Retrieve(all, question, ai.VaultPassages, ai.VaultTokens) {
+		out = append(out, ai.NoteContext{ID: p.Note.ID, Title: p.Note.Title, Section: p.Heading, Tags: p.Note.Tags, Body: p.Text})
+	}
+	return out
+}
+
+// newAIClient returns a client for the provider cfg selects.
+func newAIClient(cfg *config.Config) *ai.Client {
+	p, err := ai.NewProvider(ai.Settings{
+		Provider: cfg.Provider,
+		APIKey:   cfg.APIKey,
+		Model:    cfg.Model,
+		BaseURL:  cfg.BaseURL,
+	})
+	if err != nil {
+		die("config error: %v", err)
+	}
+	return ai.NewClient(p)
+}
+
 func ensureWelcome(store *notes.Store) {
 	all, err := store.LoadAll()
 	if err != nil || len(all) > 0 {
@@ -275,7 +797,7 @@ Your knowledge garden in the terminal. Notes are plain markdown files — yours
 | **n** | new note |
 | **N** | new note with template |
 | **t** | today's daily note |
-| **/** | fuzzy search |
+| **/** | search |
 | **e** | edit in $EDITOR |
 | **A** | ask AI about this note |
 | **@** | vault-wide AI |
@@ -298,12 +820,115 @@ grove stats                      # show vault statistics
 
 - Use **daily notes** (` + "`t`" + `) as your inbox. Dump everything there, clean up later.
 - Use **tags** in frontmatter: ` + "`tags: [work, ideas]`" + ` — searchable from ` + "`/`" + `.
-- **AI** (` + "`A`" + `) uses your Gemini key from pairy config — ask anything about a note.
+- **AI** (` + "`A`" + `) uses Gemini by default (your key from pairy config), or OpenAI-compatible APIs and Ollama — ask anything about a note.
 - Notes live in ` + "`~/.local/share/grove/notes/`" + ` — plain ` + "`.md`" + ` files, no lock-in.
 
 Happy gardening.
 `
-	_ = store.Save(note)
+	if err := store.Save(note); err != nil {
+		die("welcome note: %v", err)
+	}
+}
+
+// findNoteID returns the ID of the note ref names, by ID or else by title
+// or alias, or "" if there is none.
+func findNoteID(all []*notes.Note, ref string) string {
+	for _, n := range all {
+		if n.ID == ref {
+			return n.ID
+		}
+	}
+	if n := notes.NewResolver(all).Resolve(ref); n != nil {
+		return n.ID
+	}
+	return ""
+}
+
+func printRenamePlan(p *notes.RenamePlan, dryRun bool) {
+	if dryRun {
+		fmt.Println("dry run — nothing changed")
+	}
+	fmt.Printf("title  %s → %s\n", p.OldTitle, p.NewTitle)
+	if p.NewID != p.OldID {
+		fmt.Printf("file   %s.md → %s.md\n", p.OldID, p.NewID)
+	}
+	fmt.Printf("links  %d in %d notes\n", p.Links(), len(p.Edits))
+	for _, e := range p.Edits {
+		fmt.Printf("  %-40s  %d\n", e.Note.ID, e.Count)
+	}
+}
+
+func printProblems(problems []notes.Problem) {
+	for i, p := range problems {
+		if i == 0 || p.Kind != problems[i-1].Kind {
+			if i > 0 {
+				fmt.Println()
+			}
+			count := 0
+			for _, q := range problems[i:] {
+				if q.Kind == p.Kind {
+					count++
+				}
+			}
+			fmt.Printf("%s (%d)\n", p.Kind, count)
+		}
+		detail := p.Detail
+		switch {
+		case p.Kind == notes.BrokenLink:
+			detail = "[[" + p.Link.String() + "]]  " + detail
+		case len(p.Others) > 0:
+			ids := make([]string, len(p.Others))
+			for i, o := range p.Others {
+				ids[i] = o.ID
+			}
+			detail += ", also " + strings.Join(ids, ", ")
+		}
+		fmt.Printf("  %-40s  %s\n", p.Note.ID, detail)
+	}
+}
+
+// printPath prints the shortest chain of links between two notes, given by
+// ID or title.
+func printPath(g *notes.Graph, all []*notes.Note, fromRef, toRef string) {
+	byID := map[string]*notes.Note{}
+	for _, n := range all {
+		byID[n.ID] = n
+	}
+	from, to := byID[findNoteID(all, fromRef)], byID[findNoteID(all, toRef)]
+	if from == nil {
+		die("graph: no note %q (see grove list)", fromRef)
+	}
+	if to == nil {
+		die("graph: no note %q (see grove list)", toRef)
+	}
+	path := g.ShortestPath(from, to)
+	if path == nil {
+		fmt.Fprintf(os.Stderr, "%s and %s are not connected\n", from.Title, to.Title)
+		os.Exit(1)
+	}
+	for i, n := range path {
+		if i > 0 {
+			arrow := "→"
+			if !g.Links(path[i-1], n) {
+				arrow = "←"
+			}
+			fmt.Printf("  %s ", arrow)
+		}
+		fmt.Print(n.Title)
+	}
+	fmt.Println()
+}
+
+// warnAliasConflicts tells the user about aliases more than one note claims,
+// since links using them can only go to one of the notes.
+func warnAliasConflicts(all []*notes.Note) {
+	for _, c := range notes.NewResolver(all).Conflicts {
+		titles := make([]string, len(c.Notes))
+		for i, n := range c.Notes {
+			titles[i] = n.Title
+		}
+		fmt.Fprintf(os.Stderr, "grove: warning: alias %q is claimed by %s\n", c.Alias, strings.Join(titles, ", "))
+	}
 }
 
 func launchEditor(editor, path string) {